@@ -0,0 +1,180 @@
+package pkgs
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"path"
+	"sort"
+	"strings"
+)
+
+// GenerateAccessors returns the Go source of nil-safe accessors for the
+// tag paths of tag, in the package of s. For the path ["Foo", "Bar"] of
+// Alice it emits
+//
+//	func GetAliceFooBar(x *Alice) (v T, ok bool)
+//	func SetAliceFooBar(x *Alice, v T)
+//
+// Get returns (zero, false) if x or a pointer on the way is nil, Set
+// allocates the nil pointer structs on the way, x must not be nil.
+// Embedded pointers of promoted fields count as hops. Paths through
+// slices, arrays and maps have no single value and are skipped.
+func (s *Struct) GenerateAccessors(tag string) ([]byte, error) {
+	g := &accessorGen{root: s, imports: make(map[string]string)}
+	names := make(map[string][]string)
+	for _, tp := range s.ComputePkgTagPaths(tag) {
+		hops, ok := s.accessorHops(tp)
+		if !ok {
+			continue
+		}
+		name := s.Name + strings.Join(tp.Path, "")
+		if other, dup := names[name]; dup {
+			return nil, fmt.Errorf("accessor %s of %v conflicts with %v", name, tp.Path, other)
+		}
+		names[name] = tp.Path
+		g.accessor(name, tp, hops)
+	}
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by pkgs; DO NOT EDIT.\n\npackage %s\n", s.Pkg.Name)
+	if len(g.imports) > 0 {
+		paths := make([]string, 0, len(g.imports))
+		for p := range g.imports {
+			paths = append(paths, p)
+		}
+		sort.Strings(paths)
+		src.WriteString("\nimport (\n")
+		for _, p := range paths {
+			if g.imports[p] == path.Base(p) {
+				fmt.Fprintf(&src, "%q\n", p)
+			} else {
+				fmt.Fprintf(&src, "%s %q\n", g.imports[p], p)
+			}
+		}
+		src.WriteString(")\n")
+	}
+	src.Write(g.body.Bytes())
+	return format.Source(src.Bytes())
+}
+
+// accessorHop is a selector on the way to the leaf, with the package
+// the type of the field is relative to
+type accessorHop struct {
+	field *Field
+	pkg   string
+}
+
+// accessorHops pairs the fields of tp with the packages of their structs,
+// it returns false for paths through containers.
+func (s *Struct) accessorHops(tp TagPath) (hops []accessorHop, ok bool) {
+	owner := s
+	for i, field := range tp.Fields {
+		hops = append(hops, accessorHop{field, owner.Pkg.Dir})
+		if i == len(tp.Fields)-1 {
+			break
+		}
+		var suffix string
+		if suffix, owner, ok = owner.Pkg.fieldStruct(field); !ok || suffix != "" {
+			return nil, false
+		}
+	}
+	return hops, true
+}
+
+type accessorGen struct {
+	root *Struct
+	// imports maps import paths to package names
+	imports map[string]string
+	body    bytes.Buffer
+}
+
+func (g *accessorGen) accessor(name string, tp TagPath, hops []accessorHop) {
+	root := g.root.Name
+	leaf := hops[len(hops)-1]
+	leafType := g.typeString(leaf.field.Ref, leaf.pkg)
+
+	var (
+		exprs  = make([]string, len(hops))
+		expr   = "x"
+		checks = []string{"x == nil"}
+		allocs []string
+	)
+	for i, hop := range hops {
+		expr += "." + hop.field.Name
+		exprs[i] = expr
+		if i < len(hops)-1 && hop.field.Ref.Kind == "pointer" {
+			checks = append(checks, expr+" == nil")
+			allocs = append(allocs, fmt.Sprintf("if %s == nil {\n%s = new(%s)\n}\n",
+				expr, expr, g.typeString(hop.field.Ref.Elem, hop.pkg)))
+		}
+	}
+
+	fmt.Fprintf(&g.body, "\n// Get%s returns x.%s, false if a pointer on the way is nil.\n",
+		name, strings.Join(tp.Path, "."))
+	fmt.Fprintf(&g.body, "func Get%s(x *%s) (v %s, ok bool) {\n", name, root, leafType)
+	fmt.Fprintf(&g.body, "if %s {\nreturn\n}\n", strings.Join(checks, " || "))
+	fmt.Fprintf(&g.body, "return %s, true\n}\n", expr)
+
+	fmt.Fprintf(&g.body, "\n// Set%s sets x.%s, allocating the nil pointers on the way.\n",
+		name, strings.Join(tp.Path, "."))
+	fmt.Fprintf(&g.body, "func Set%s(x *%s, v %s) {\n", name, root, leafType)
+	for _, alloc := range allocs {
+		g.body.WriteString(alloc)
+	}
+	fmt.Fprintf(&g.body, "%s = v\n}\n", expr)
+}
+
+// typeString renders ref relative to pkg in the package of the root,
+// adding the imports it needs
+func (g *accessorGen) typeString(ref *TypeRef, pkg string) string {
+	switch ref.Kind {
+	case "named":
+		p := ref.Pkg
+		if p == "" {
+			p = pkg
+		}
+		if p == g.root.Pkg.Dir {
+			return ref.Name
+		}
+		return g.importName(p) + "." + ref.Name
+	case "pointer":
+		return "*" + g.typeString(ref.Elem, pkg)
+	case "slice":
+		return "[]" + g.typeString(ref.Elem, pkg)
+	case "array":
+		return fmt.Sprintf("[%d]%s", ref.Len, g.typeString(ref.Elem, pkg))
+	case "map":
+		return "map[" + g.typeString(ref.Key, pkg) + "]" + g.typeString(ref.Elem, pkg)
+	case "chan":
+		return "chan " + g.typeString(ref.Elem, pkg)
+	}
+	return ref.Name
+}
+
+func (g *accessorGen) importName(p string) string {
+	if name, ok := g.imports[p]; ok {
+		return name
+	}
+	base := strings.Map(func(r rune) rune {
+		if r == '-' || r == '.' {
+			return '_'
+		}
+		return r
+	}, path.Base(p))
+	name := base
+	for i := 2; g.nameTaken(name); i++ {
+		name = fmt.Sprintf("%s%d", base, i)
+	}
+	g.imports[p] = name
+	return name
+}
+
+func (g *accessorGen) nameTaken(name string) bool {
+	for _, taken := range g.imports {
+		if taken == name {
+			return true
+		}
+	}
+	return false
+}
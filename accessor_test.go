@@ -0,0 +1,88 @@
+package pkgs
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// checkAccessors type checks src with the files of the fixture package dir
+func checkAccessors(dir string, src []byte) error {
+	fs := token.NewFileSet()
+	names, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+	var files []*ast.File
+	for _, name := range names {
+		file, err := parser.ParseFile(fs, name, nil, 0)
+		if err != nil {
+			return err
+		}
+		files = append(files, file)
+	}
+	file, err := parser.ParseFile(fs, "accessors.go", src, 0)
+	if err != nil {
+		return err
+	}
+	config := types.Config{Importer: importer.Default()}
+	_, err = config.Check(dir, fs, append(files, file), nil)
+	return err
+}
+
+func TestGenerateAccessors(t *testing.T) {
+
+	Convey("Generate accessors of fixture accessor package", t, func() {
+		pkg := NewPackage("fixture/accessor")
+
+		src, err := pkg.StructTypes["Alice"].GenerateAccessors("ACC")
+		So(err, ShouldBeNil)
+		So(checkAccessors("fixture/accessor", src), ShouldBeNil)
+
+		code := string(src)
+		So(code, ShouldContainSubstring, "package accessor\n")
+		So(code, ShouldContainSubstring, `func GetAliceFooBarCount(x *Alice) (v int, ok bool) {
+	if x == nil || x.Foo == nil || x.Foo.Bar == nil {
+		return
+	}
+	return x.Foo.Bar.Count, true
+}`)
+		So(code, ShouldContainSubstring, `func SetAliceFooBarCount(x *Alice, v int) {
+	if x.Foo == nil {
+		x.Foo = new(Foo)
+	}
+	if x.Foo.Bar == nil {
+		x.Foo.Bar = new(Bar)
+	}
+	x.Foo.Bar.Count = v
+}`)
+		So(code, ShouldContainSubstring, `func SetAliceProfileOwner(x *Alice, v string) {
+	if x.Profile.Meta == nil {
+		x.Profile.Meta = new(Meta)
+	}
+	x.Profile.Meta.Owner = v
+}`)
+		So(code, ShouldContainSubstring, "func GetAliceProfileNick(")
+		So(code, ShouldContainSubstring, "func GetAliceFooName(")
+		So(code, ShouldNotContainSubstring, "Foos")
+	})
+
+	Convey("Generate accessors through structs of imported packages", t, func() {
+		pkg := NewPackage("fixture/accessor")
+
+		src, err := pkg.StructTypes["Cert"].GenerateAccessors("asn1")
+		So(err, ShouldBeNil)
+		So(checkAccessors("fixture/accessor", src), ShouldBeNil)
+
+		code := string(src)
+		So(code, ShouldContainSubstring, `import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+)`)
+		So(code, ShouldContainSubstring, "func GetCertAlgParameters(x *Cert) (v asn1.RawValue, ok bool) {")
+		So(code, ShouldContainSubstring, "x.Alg = new(pkix.AlgorithmIdentifier)")
+	})
+}
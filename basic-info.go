@@ -0,0 +1,98 @@
+package pkgs
+
+import "go/types"
+
+// BasicInfo classifies a basic type, so generators need not switch on names.
+type BasicInfo struct {
+	Integer  bool
+	Unsigned bool
+	Float    bool
+	Complex  bool
+	String   bool
+	Boolean  bool
+	Untyped  bool
+	// Bits is the size in bits, 0 for string, bool and unsafe.Pointer.
+	// The bits of int, uint and uintptr depend on the GOARCH, they are 0
+	// if it is unknown.
+	Bits int
+	// Zero is the Go literal of the zero value
+	Zero string
+}
+
+var basicInfos = make(map[string]BasicInfo)
+
+func init() {
+	for _, t := range types.Typ {
+		basicInfos[t.Name()] = newBasicInfo(t)
+	}
+	for _, t := range []*types.Basic{types.Universe.Lookup("byte").Type().(*types.Basic),
+		types.Universe.Lookup("rune").Type().(*types.Basic)} {
+		basicInfos[t.Name()] = newBasicInfo(t)
+	}
+}
+
+func newBasicInfo(t *types.Basic) BasicInfo {
+	info := t.Info()
+	bi := BasicInfo{
+		Integer:  info&types.IsInteger != 0,
+		Unsigned: info&types.IsUnsigned != 0,
+		Float:    info&types.IsFloat != 0,
+		Complex:  info&types.IsComplex != 0,
+		String:   info&types.IsString != 0,
+		Boolean:  info&types.IsBoolean != 0,
+		Untyped:  info&types.IsUntyped != 0,
+	}
+
+	switch t.Kind() {
+	case types.Int8, types.Uint8:
+		bi.Bits = 8
+	case types.Int16, types.Uint16:
+		bi.Bits = 16
+	case types.Int32, types.Uint32, types.Float32:
+		bi.Bits = 32
+	case types.Int64, types.Uint64, types.Float64, types.Complex64:
+		bi.Bits = 64
+	case types.Complex128:
+		bi.Bits = 128
+	}
+
+	switch {
+	case bi.String:
+		bi.Zero = `""`
+	case bi.Boolean:
+		bi.Zero = "false"
+	case info&types.IsNumeric != 0:
+		bi.Zero = "0"
+	default:
+		bi.Zero = "nil"
+	}
+	return bi
+}
+
+// LookupBasicInfo returns the info of a basic type name like "uint8".
+// The Bits of int, uint and uintptr are computed with the gc sizes of
+// goarch, like Package.Arch, and are 0 for an empty or unknown goarch.
+func LookupBasicInfo(name, goarch string) (BasicInfo, bool) {
+	bi, ok := basicInfos[name]
+	if !ok || bi.Bits != 0 || !bi.Integer || bi.Untyped {
+		return bi, ok
+	}
+	if sizes := types.SizesFor("gc", goarch); sizes != nil {
+		bi.Bits = int(sizes.Sizeof(types.Universe.Lookup(name).Type())) * 8
+	}
+	return bi, true
+}
+
+// Info returns the classification of the basic type for goarch.
+func (b *Basic) Info(goarch string) BasicInfo {
+	bi, _ := LookupBasicInfo(b.Type, goarch)
+	return bi
+}
+
+// BasicInfo returns the classification of a basic ref for goarch.
+func (ref *TypeRef) BasicInfo(goarch string) (BasicInfo, bool) {
+	if ref.Kind != "basic" {
+		return BasicInfo{}, false
+	}
+	return LookupBasicInfo(ref.Name, goarch)
+}
@@ -0,0 +1,71 @@
+package pkgs
+
+import (
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestBasicInfo(t *testing.T) {
+
+	Convey("Classify basic types", t, func() {
+		bi, ok := LookupBasicInfo("uint8", "")
+		So(ok, ShouldBeTrue)
+		So(bi, ShouldResemble, BasicInfo{Integer: true, Unsigned: true, Bits: 8, Zero: "0"})
+
+		bi, _ = LookupBasicInfo("byte", "")
+		So(bi.Bits, ShouldEqual, 8)
+
+		bi, _ = LookupBasicInfo("float64", "")
+		So(bi, ShouldResemble, BasicInfo{Float: true, Bits: 64, Zero: "0"})
+
+		bi, _ = LookupBasicInfo("complex128", "")
+		So(bi.Complex, ShouldBeTrue)
+		So(bi.Bits, ShouldEqual, 128)
+
+		bi, _ = LookupBasicInfo("string", "")
+		So(bi, ShouldResemble, BasicInfo{String: true, Zero: `""`})
+
+		bi, _ = LookupBasicInfo("bool", "")
+		So(bi, ShouldResemble, BasicInfo{Boolean: true, Zero: "false"})
+
+		bi, _ = LookupBasicInfo("untyped int", "")
+		So(bi.Untyped, ShouldBeTrue)
+		So(bi.Integer, ShouldBeTrue)
+
+		bi, _ = LookupBasicInfo("int", "")
+		So(bi.Bits, ShouldEqual, 0)
+
+		bi, _ = LookupBasicInfo("int", "amd64")
+		So(bi, ShouldResemble, BasicInfo{Integer: true, Bits: 64, Zero: "0"})
+
+		bi, _ = LookupBasicInfo("uintptr", "386")
+		So(bi.Bits, ShouldEqual, 32)
+
+		bi, _ = LookupBasicInfo("int8", "386")
+		So(bi.Bits, ShouldEqual, 8)
+
+		_, ok = LookupBasicInfo("Foo", "")
+		So(ok, ShouldBeFalse)
+	})
+
+	Convey("Classify basic types of fixture foo package", t, func() {
+		files, err := filepath.Glob("./fixture/foo/*.go")
+		So(err, ShouldBeNil)
+		pkg := NewPackage(files...)
+
+		So(pkg.ComputeLayout("arm"), ShouldBeNil)
+
+		bi := pkg.BasicTypes["Int"].Info(pkg.Arch)
+		So(bi.Integer, ShouldBeTrue)
+		So(bi.Bits, ShouldEqual, 32)
+
+		bi, ok := pkg.StructTypes["Foo"].FieldMap["ID"].Ref.BasicInfo(pkg.Arch)
+		So(ok, ShouldBeTrue)
+		So(bi.Unsigned, ShouldBeTrue)
+
+		_, ok = pkg.StructTypes["Alice"].FieldMap["Foo"].Ref.BasicInfo(pkg.Arch)
+		So(ok, ShouldBeFalse)
+	})
+}
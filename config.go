@@ -0,0 +1,366 @@
+package pkgs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// configSchema is the JSON Schema of autogens config files, published
+// as autogens.schema.json. validateConfig understands the subset used
+// here: type, properties, additionalProperties, items and local $ref.
+var configSchema = map[string]interface{}{
+	"$schema":              "http://json-schema.org/draft-07/schema#",
+	"title":                "autogens",
+	"description":          "Options of the generators run on a package, keyed by tool name.",
+	"type":                 "object",
+	"additionalProperties": map[string]interface{}{"$ref": "#/definitions/tool"},
+	"definitions": map[string]interface{}{
+		"tool": map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": false,
+			"properties": map[string]interface{}{
+				"Command": map[string]interface{}{
+					"type":        "string",
+					"description": "The command of the tool.",
+				},
+				"Presets": map[string]interface{}{
+					"type":        "object",
+					"description": "Named options, referenced by \"&name\" strings and extended by \"$extends\" maps.",
+				},
+				"Ignore": map[string]interface{}{
+					"type":        "array",
+					"description": "Selectors of the types to move from Types to Ignored.",
+					"items":       map[string]interface{}{"type": "string"},
+				},
+				"Types": map[string]interface{}{
+					"type":        "object",
+					"description": "Options of the package types, keyed by type name, \"*\", glob, /regexp/ or selector. Exact names win over patterns.",
+				},
+				"Data": map[string]interface{}{
+					"description": "Free data of the tool, \"&name\" strings reference presets and \"$Name\" strings package constants.",
+				},
+			},
+		},
+	},
+}
+
+// ConfigSchema returns the JSON Schema of autogens config files
+func ConfigSchema() []byte {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	enc.Encode(configSchema)
+	return buf.Bytes()
+}
+
+// ConfigError is a config value not matching the schema. Line and Column
+// are 1-based, 0 if the source has no positions.
+type ConfigError struct {
+	File   string
+	Path   []string
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *ConfigError) Error() string {
+	pos := e.File
+	if e.Line > 0 {
+		pos = fmt.Sprintf("%s:%d:%d", e.File, e.Line, e.Column)
+	}
+	return fmt.Sprintf("%s: %s: %s", pos, strings.Join(e.Path, "."), e.Msg)
+}
+
+// ConfigErrors are all the errors of a config file, in source order
+type ConfigErrors []*ConfigError
+
+func (errs ConfigErrors) Error() string {
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// validateConfig checks the decoded config of file against configSchema.
+// src is the JSON5 source for positions, nil for other formats.
+func validateConfig(file string, src []byte, raw map[string]interface{}) error {
+	v := &configValidator{file: file}
+	if src != nil {
+		// on syntax errors, reported by the decoder, errors have no position
+		if positions, err := json5Positions(src); err == nil {
+			v.positions = positions
+		}
+	}
+	v.validate(nil, raw, configSchema)
+	if len(v.errs) == 0 {
+		return nil
+	}
+	sort.SliceStable(v.errs, func(i, j int) bool {
+		a, b := v.errs[i], v.errs[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return v.errs
+}
+
+type configValidator struct {
+	file      string
+	positions map[string][2]int
+	errs      ConfigErrors
+}
+
+func (v *configValidator) errorf(path []string, format string, args ...interface{}) {
+	pos := v.positions[strings.Join(path, "\x00")]
+	v.errs = append(v.errs, &ConfigError{
+		File:   v.file,
+		Path:   path,
+		Line:   pos[0],
+		Column: pos[1],
+		Msg:    fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *configValidator) validate(path []string, value interface{}, schema map[string]interface{}) {
+	if ref, ok := schema["$ref"].(string); ok {
+		schema = lookupSchema(ref)
+	}
+	if typ, ok := schema["type"].(string); ok && typ != jsonType(value) {
+		v.errorf(path, "expected %s, got %s", typ, jsonType(value))
+		return
+	}
+
+	switch value := value.(type) {
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(map[string]interface{})
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			keyPath := appendPath(path, key)
+			if sub, ok := properties[key].(map[string]interface{}); ok {
+				v.validate(keyPath, value[key], sub)
+				continue
+			}
+			switch additional := schema["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					v.errorf(keyPath, "unknown key %q", key)
+				}
+			case map[string]interface{}:
+				v.validate(keyPath, value[key], additional)
+			}
+		}
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, e := range value {
+				v.validate(appendPath(path, fmt.Sprint(i)), e, items)
+			}
+		}
+	}
+}
+
+// lookupSchema resolves "#/definitions/name"
+func lookupSchema(ref string) map[string]interface{} {
+	definitions := configSchema["definitions"].(map[string]interface{})
+	schema, _ := definitions[strings.TrimPrefix(ref, "#/definitions/")].(map[string]interface{})
+	return schema
+}
+
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// json5Positions maps the paths of object keys and array elements, joined
+// by "\x00", to their line and column in src. It returns a
+// *json5ScanError at the first syntax error.
+func json5Positions(src []byte) (positions map[string][2]int, err error) {
+	s := &json5Scanner{src: src, line: 1, col: 1, positions: make(map[string][2]int)}
+	defer func() {
+		if r := recover(); r != nil {
+			scanErr, ok := r.(*json5ScanError)
+			if !ok {
+				panic(r)
+			}
+			positions, err = nil, scanErr
+		}
+	}()
+	s.value(nil)
+	return s.positions, nil
+}
+
+// json5ScanError is a syntax error found by json5Scanner
+type json5ScanError struct {
+	Line, Column int
+	Msg          string
+}
+
+func (e *json5ScanError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Msg)
+}
+
+type json5Scanner struct {
+	src       []byte
+	i         int
+	line, col int
+	positions map[string][2]int
+}
+
+// fail stops the scan with a *json5ScanError at the current position
+func (s *json5Scanner) fail(msg string) {
+	panic(&json5ScanError{Line: s.line, Column: s.col, Msg: msg})
+}
+
+func (s *json5Scanner) peek() byte {
+	if s.i >= len(s.src) {
+		s.fail("unexpected end of input")
+	}
+	return s.src[s.i]
+}
+
+func (s *json5Scanner) next() byte {
+	c := s.peek()
+	s.i++
+	if c == '\n' {
+		s.line, s.col = s.line+1, 1
+	} else {
+		s.col++
+	}
+	return c
+}
+
+func (s *json5Scanner) skip() {
+	for s.i < len(s.src) {
+		switch c := s.src[s.i]; {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			s.next()
+		case c == '/' && s.i+1 < len(s.src) && s.src[s.i+1] == '/':
+			for s.i < len(s.src) && s.src[s.i] != '\n' {
+				s.next()
+			}
+		case c == '/' && s.i+1 < len(s.src) && s.src[s.i+1] == '*':
+			s.next()
+			s.next()
+			for !(s.peek() == '*' && s.i+1 < len(s.src) && s.src[s.i+1] == '/') {
+				s.next()
+			}
+			s.next()
+			s.next()
+		default:
+			return
+		}
+	}
+}
+
+func (s *json5Scanner) mark(path []string) {
+	s.positions[strings.Join(path, "\x00")] = [2]int{s.line, s.col}
+}
+
+func (s *json5Scanner) value(path []string) {
+	s.skip()
+	switch c := s.peek(); c {
+	case '{':
+		s.next()
+		for {
+			s.skip()
+			if s.peek() == '}' {
+				s.next()
+				return
+			}
+			line, col := s.line, s.col
+			key := s.key()
+			keyPath := appendPath(path, key)
+			s.positions[strings.Join(keyPath, "\x00")] = [2]int{line, col}
+			s.skip()
+			if s.peek() != ':' {
+				s.fail("colon expected")
+			}
+			s.next()
+			s.value(keyPath)
+			s.comma('}')
+		}
+	case '[':
+		s.next()
+		for i := 0; ; i++ {
+			s.skip()
+			if s.peek() == ']' {
+				s.next()
+				return
+			}
+			s.mark(appendPath(path, fmt.Sprint(i)))
+			s.value(appendPath(path, fmt.Sprint(i)))
+			s.comma(']')
+		}
+	case '"', '\'':
+		s.str()
+	default:
+		for s.i < len(s.src) && !strings.ContainsRune(",]} \t\r\n/", rune(s.src[s.i])) {
+			s.next()
+		}
+	}
+}
+
+func (s *json5Scanner) comma(end byte) {
+	s.skip()
+	if s.peek() == ',' {
+		s.next()
+	} else if s.peek() != end {
+		s.fail("comma expected")
+	}
+}
+
+func (s *json5Scanner) key() string {
+	if c := s.peek(); c == '"' || c == '\'' {
+		return s.str()
+	}
+	start := s.i
+	for s.i < len(s.src) && !strings.ContainsRune(": \t\r\n/", rune(s.src[s.i])) {
+		s.next()
+	}
+	return string(s.src[start:s.i])
+}
+
+func (s *json5Scanner) str() string {
+	quote := s.next()
+	var buf []byte
+	for {
+		c := s.next()
+		switch c {
+		case quote:
+			return string(buf)
+		case '\\':
+			switch e := s.next(); e {
+			case 'n':
+				buf = append(buf, '\n')
+			case 't':
+				buf = append(buf, '\t')
+			default:
+				buf = append(buf, e)
+			}
+		default:
+			buf = append(buf, c)
+		}
+	}
+}
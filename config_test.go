@@ -0,0 +1,138 @@
+package pkgs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestConfigDiscovery(t *testing.T) {
+
+	Convey("Merge configs of parent directories into fixture config child package", t, func() {
+		pkg := NewPackage("fixture/config/child")
+
+		pager := pkg.Tools["pager"]
+		So(pager, ShouldNotBeNil)
+		So(pager.Data, ShouldResemble, map[string]interface{}{
+			"style": "offset",
+			"owner": "child",
+		})
+		So(pager.Types["Query"], ShouldResemble, map[string]interface{}{
+			"size": float64(20),
+			"max":  float64(50),
+		})
+
+		validator := pkg.Tools["validator"]
+		So(validator, ShouldNotBeNil)
+		So(validator.Ignore, ShouldResemble, []string{"basic"})
+	})
+
+	Convey("Skip parent Types entries of fixture config sibling package", t, func() {
+		child := NewPackage("fixture/config/child")
+		So(child.Tools["pager"].Types, ShouldNotContainKey, "Sibling")
+
+		sibling := NewPackage("fixture/config/sibling")
+		So(sibling.Tools["pager"].Types, ShouldResemble, map[string]interface{}{
+			"Sibling": map[string]interface{}{"size": float64(10)},
+		})
+	})
+
+	Convey("Load fixture plain package without config", t, func() {
+		pkg := NewPackage("fixture/plain")
+		So(pkg.StructTypes["Plain"], ShouldNotBeNil)
+		So(pkg.Tools, ShouldBeEmpty)
+	})
+
+	Convey("Stop config discovery at the module root", t, func() {
+		dirs := configDirs("fixture/config/child")
+		So(len(dirs), ShouldBeGreaterThanOrEqualTo, 3)
+		So(dirs[len(dirs)-1], ShouldEndWith, "fixture/config/child")
+		So(exists(dirs[0]+"/go.mod") || exists(dirs[0]+"/.git"), ShouldBeTrue)
+	})
+
+	Convey("Read only the package config outside of modules", t, func() {
+		tmp, err := ioutil.TempDir("", "autogens")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(tmp)
+		dir := filepath.Join(tmp, "a", "b")
+		So(os.MkdirAll(dir, 0755), ShouldBeNil)
+		So(ioutil.WriteFile(filepath.Join(tmp, "autogens.json5"), []byte("{}"), 0644), ShouldBeNil)
+
+		So(configDirs(dir), ShouldResemble, []string{dir})
+	})
+}
+
+func TestConfigFormats(t *testing.T) {
+
+	Convey("Decode json5, yaml and toml configs the same way", t, func() {
+		expected := NewPackage("fixture/formats/json5").Tools["tagsjson"]
+		So(expected.Types["Bar"], ShouldResemble, map[string]interface{}{"VIEW": "dev", "MGR": "dev"})
+		So(expected.Data.(map[string]interface{})["limit"], ShouldEqual, float64(20))
+
+		for _, format := range []string{"yaml", "toml"} {
+			opt := NewPackage("fixture/formats/" + format).Tools["tagsjson"]
+			So(opt, ShouldNotBeNil)
+			So(opt.Command, ShouldEqual, expected.Command)
+			So(opt.Ignore, ShouldResemble, expected.Ignore)
+			So(opt.Ignored, ShouldResemble, expected.Ignored)
+			So(opt.Presets, ShouldResemble, expected.Presets)
+			So(opt.Types, ShouldResemble, expected.Types)
+			So(opt.Data, ShouldResemble, expected.Data)
+		}
+	})
+}
+
+func TestConfigSchema(t *testing.T) {
+
+	Convey("Publish the config schema", t, func() {
+		published, err := ioutil.ReadFile("autogens.schema.json")
+		So(err, ShouldBeNil)
+		So(string(published), ShouldEqual, string(ConfigSchema()))
+	})
+
+	Convey("Report unknown keys and wrong shapes with positions", t, func() {
+		_, err := readConfig("fixture/strict")
+		So(err, ShouldNotBeNil)
+
+		errs, ok := err.(ConfigErrors)
+		So(ok, ShouldBeTrue)
+		So(errs, ShouldHaveLength, 4)
+
+		file := filepath.Join("fixture/strict", "autogens.json5")
+		So(errs[0].Error(), ShouldEqual, file+`:5:5: tagsjson.Ignores: unknown key "Ignores"`)
+		So(errs[1].Error(), ShouldEqual, file+`:6:5: tagsjson.preset: unknown key "preset"`)
+		So(errs[2].Error(), ShouldEqual, file+`:9:5: tagsjson.Types: expected object, got array`)
+		So(errs[3].Error(), ShouldEqual, file+`:12:23: pkshow.Ignore.1: expected string, got number`)
+	})
+
+	Convey("Report errors of other formats without positions", t, func() {
+		err := validateConfig("autogens.yaml", nil, map[string]interface{}{
+			"tagsjson": map[string]interface{}{"Ignores": []interface{}{"basic"}},
+		})
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldEqual, `autogens.yaml: tagsjson.Ignores: unknown key "Ignores"`)
+	})
+
+	Convey("Scan json5 positions until syntax errors", t, func() {
+		positions, err := json5Positions([]byte("{\n  tagsjson: {Types: [1, 2]},\n}"))
+		So(err, ShouldBeNil)
+		So(positions["tagsjson\x00Types"], ShouldResemble, [2]int{2, 14})
+		So(positions["tagsjson\x00Types\x001"], ShouldResemble, [2]int{2, 25})
+
+		positions, err = json5Positions([]byte("{\n  tagsjson {}\n}"))
+		So(positions, ShouldBeNil)
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldEqual, "2:12: colon expected")
+
+		_, err = json5Positions([]byte("{tagsjson: [1"))
+		So(err.Error(), ShouldEqual, "1:14: unexpected end of input")
+
+		err = validateConfig("autogens.json5", []byte("{tagsjson {}}"), map[string]interface{}{
+			"tagsjson": map[string]interface{}{"Ignores": []interface{}{"basic"}},
+		})
+		So(err.Error(), ShouldEqual, `autogens.json5: tagsjson.Ignores: unknown key "Ignores"`)
+	})
+}
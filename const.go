@@ -0,0 +1,156 @@
+package pkgs
+
+import (
+	"encoding/json"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+)
+
+// Const is a package level constant with its evaluated value
+type Const struct {
+	Name       string
+	Doc        string
+	Pos        token.Position
+	TypeString string
+	Ref        *TypeRef
+	Exported   bool
+	// Untyped reports an untyped constant, like DefaultPageSize = 20
+	Untyped bool
+	// Kind is one of bool, string, int, float, complex
+	Kind string
+	// Exact is the exact go/constant representation of the value
+	Exact string
+	Value constant.Value `json:"-"`
+}
+
+var constKinds = map[constant.Kind]string{
+	constant.Bool:    "bool",
+	constant.String:  "string",
+	constant.Int:     "int",
+	constant.Float:   "float",
+	constant.Complex: "complex",
+}
+
+func NewConst(obj *types.Const, p *Package) *Const {
+	basic, isBasic := obj.Type().(*types.Basic)
+	return &Const{
+		Name:       obj.Name(),
+		Pos:        p.position(obj.Pos()),
+		TypeString: types.TypeString(obj.Type(), types.RelativeTo(p.TypesPkg)),
+		Ref:        NewTypeRef(obj.Type(), p.TypesPkg),
+		Exported:   obj.Exported(),
+		Untyped:    isBasic && basic.Info()&types.IsUntyped != 0,
+		Kind:       constKinds[obj.Val().Kind()],
+		Exact:      obj.Val().ExactString(),
+		Value:      obj.Val(),
+	}
+}
+
+// Interface returns the value as bool, string, int64, uint64, float64
+// or complex128. Integers that overflow 64 bits and inexact floats
+// are approximated by float64.
+func (c *Const) Interface() interface{} {
+	v := c.Value
+	switch v.Kind() {
+	case constant.Bool:
+		return constant.BoolVal(v)
+	case constant.String:
+		return constant.StringVal(v)
+	case constant.Int:
+		if i, ok := constant.Int64Val(v); ok {
+			return i
+		}
+		if u, ok := constant.Uint64Val(v); ok {
+			return u
+		}
+	case constant.Complex:
+		re, _ := constant.Float64Val(constant.Real(v))
+		im, _ := constant.Float64Val(constant.Imag(v))
+		return complex(re, im)
+	}
+	f, _ := constant.Float64Val(v)
+	return f
+}
+
+// UnmarshalJSON restores Value from Exact
+func (c *Const) UnmarshalJSON(data []byte) error {
+	type jsonConst Const
+	if err := json.Unmarshal(data, (*jsonConst)(c)); err != nil {
+		return err
+	}
+	c.Value = parseExact(c.Kind, c.Exact)
+	return nil
+}
+
+// parseExact is the reverse of constant.Value.ExactString
+func parseExact(kind, exact string) constant.Value {
+	switch kind {
+	case "bool":
+		return constant.MakeBool(exact == "true")
+	case "string":
+		return constant.MakeFromLiteral(exact, token.STRING, 0)
+	case "int":
+		return constant.MakeFromLiteral(exact, token.INT, 0)
+	case "float":
+		if i := strings.Index(exact, "/"); i != -1 {
+			num := constant.MakeFromLiteral(exact[:i], token.INT, 0)
+			den := constant.MakeFromLiteral(exact[i+1:], token.INT, 0)
+			return constant.BinaryOp(num, token.QUO, den)
+		}
+		return constant.MakeFromLiteral(exact, token.FLOAT, 0)
+	case "complex":
+		// (re + imi)
+		parts := strings.SplitN(strings.Trim(exact, "()"), " + ", 2)
+		if len(parts) == 2 {
+			re := parseExact("float", parts[0])
+			im := parseExact("float", strings.TrimSuffix(parts[1], "i"))
+			return constant.BinaryOp(re, token.ADD, constant.MakeImag(im))
+		}
+	}
+	return constant.MakeUnknown()
+}
+
+// ConstValue looks up a package constant by name, see Const.Interface.
+func (p *Package) ConstValue(name string) (interface{}, bool) {
+	c, ok := p.Consts[name]
+	if !ok {
+		return nil, false
+	}
+	return c.Interface(), true
+}
+
+// resolveConsts replaces "$Name" strings in v by the value of the
+// constant Name, recursively in maps and arrays. "$$" escapes a
+// literal "$", strings naming no constant like "$HOME" are kept.
+func (p *Package) resolveConsts(v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		if strings.HasPrefix(v, "$$") {
+			return v[1:]
+		}
+		if !strings.HasPrefix(v, "$") {
+			return v
+		}
+		value, ok := p.ConstValue(v[1:])
+		if !ok {
+			log.WithFields(logrus.Fields{
+				"const": v,
+			}).Warn("Const not found, keep the string")
+			return v
+		}
+		return value
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = p.resolveConsts(e)
+		}
+	case []interface{}:
+		for i, e := range v {
+			v[i] = p.resolveConsts(e)
+		}
+	}
+	return v
+}
@@ -0,0 +1,64 @@
+package pkgs
+
+import (
+	"encoding/json"
+	"go/constant"
+	"go/token"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestConsts(t *testing.T) {
+
+	Convey("Parse consts of fixture consts package", t, func() {
+		pkg := NewPackage("fixture/consts")
+		So(len(pkg.Consts), ShouldEqual, 9)
+
+		size := pkg.Consts["DefaultPageSize"]
+		So(size.Doc, ShouldEqual, "DefaultPageSize is the page size when not specified\n")
+		So(size.Untyped, ShouldBeTrue)
+		So(size.Kind, ShouldEqual, "int")
+		So(size.TypeString, ShouldEqual, "untyped int")
+
+		info := pkg.Consts["Info"]
+		So(info.Untyped, ShouldBeFalse)
+		So(info.TypeString, ShouldEqual, "Level")
+		So(info.Doc, ShouldEqual, "Levels\n")
+
+		values := make(map[string]interface{})
+		for name := range pkg.Consts {
+			values[name], _ = pkg.ConstValue(name)
+		}
+		So(values, ShouldResemble, map[string]interface{}{
+			"Debug":           int64(0),
+			"Info":            int64(1),
+			"DefaultPageSize": int64(20),
+			"MaxSize":         int64(1 << 40),
+			"Ratio":           1.0 / 3,
+			"Greeting":        "hello",
+			"enabled":         true,
+			"Big":             float64(1 << 70),
+			"Wave":            complex(1, 2),
+		})
+		_, ok := pkg.ConstValue("Nope")
+		So(ok, ShouldBeFalse)
+
+		So(pkg.Tools["pager"].Data, ShouldResemble, map[string]interface{}{
+			"size":     int64(20),
+			"levels":   []interface{}{int64(0), int64(1)},
+			"greeting": "hello",
+			"price":    "$5",
+			"home":     "$HOME",
+			"cost":     "$1.00",
+		})
+
+		data, err := json.Marshal(pkg)
+		So(err, ShouldBeNil)
+		var imported Package
+		So(json.Unmarshal(data, &imported), ShouldBeNil)
+		for name, c := range pkg.Consts {
+			So(constant.Compare(imported.Consts[name].Value, token.EQL, c.Value), ShouldBeTrue)
+		}
+	})
+}
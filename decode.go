@@ -0,0 +1,201 @@
+//go:build go1.18
+// +build go1.18
+
+package pkgs
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// DecodeTool decodes the Data of tool into a T. Fields of T missing in
+// Data keep the value of their `default:"..."` tag, a missing tool gives
+// the defaults. See DecodeOptions.
+func DecodeTool[T any](p *Package, tool string) (T, error) {
+	var data interface{}
+	if opt, ok := p.Tools[tool]; ok {
+		data = opt.Data
+	}
+	v, err := DecodeOptions[T](data)
+	if err != nil {
+		return v, fmt.Errorf("tool %s: %s", tool, err)
+	}
+	return v, nil
+}
+
+// DecodeTypeOptions decodes the options of the type typ into a T. The
+// fields section is left to FieldOptions and DecodeFieldOptions.
+func DecodeTypeOptions[T any](opt *JsonOptions, typ string) (T, error) {
+	option := opt.Types[typ]
+	if m, ok := option.(map[string]interface{}); ok {
+		if _, ok := m[typeFieldsKey]; ok {
+			own := make(map[string]interface{}, len(m))
+			for k, v := range m {
+				if k != typeFieldsKey {
+					own[k] = v
+				}
+			}
+			option = own
+		}
+	}
+	v, err := DecodeOptions[T](option)
+	if err != nil {
+		return v, fmt.Errorf("type %s: %s", typ, err)
+	}
+	return v, nil
+}
+
+// DecodeFieldOptions decodes FieldOptions of the field of s into a T.
+func DecodeFieldOptions[T any](opt *JsonOptions, s *Struct, field *Field) (T, error) {
+	option, _ := opt.FieldOptions(s, field)
+	v, err := DecodeOptions[T](option)
+	if err != nil {
+		return v, fmt.Errorf("field %s.%s: %s", s.Name, field.Name, err)
+	}
+	return v, nil
+}
+
+// DecodeOptions decodes an option value into a T, strictly: unknown keys
+// are errors. Before decoding, the fields of T are set from their
+// `default:"..."` tags, comma separated for slices. Slices and maps of
+// the option replace their defaults. Strings decode into
+// time.Duration by time.ParseDuration, and into enum types implementing
+// encoding.TextUnmarshaler by UnmarshalText.
+func DecodeOptions[T any](option interface{}) (T, error) {
+	var v T
+	if err := setDefaults(reflect.ValueOf(&v).Elem()); err != nil {
+		return v, err
+	}
+	if option == nil {
+		return v, nil
+	}
+
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(
+			mapstructure.StringToTimeDurationHookFunc(),
+			textUnmarshalerHook,
+		),
+		ErrorUnused: true,
+		// config slices and maps replace the defaults, not merge into them
+		ZeroFields: true,
+		Result:     &v,
+	})
+	if err == nil {
+		err = decoder.Decode(option)
+	}
+	return v, err
+}
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// textUnmarshalerHook decodes strings into enum types
+func textUnmarshalerHook(from, to reflect.Type, data interface{}) (interface{}, error) {
+	if from == nil || from.Kind() != reflect.String || !reflect.PointerTo(to).Implements(textUnmarshalerType) {
+		return data, nil
+	}
+	v := reflect.New(to)
+	if err := v.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(data.(string))); err != nil {
+		return nil, err
+	}
+	return v.Elem().Interface(), nil
+}
+
+// setDefaults sets the fields of the struct v from their default tags,
+// descending into struct fields.
+func setDefaults(v reflect.Value) error {
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+		field := v.Field(i)
+		def, ok := sf.Tag.Lookup("default")
+		if !ok {
+			if err := setDefaults(field); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := setDefault(field, def); err != nil {
+			return fmt.Errorf("default of %s.%s: %s", t.Name(), sf.Name, err)
+		}
+	}
+	return nil
+}
+
+func setDefault(v reflect.Value, def string) error {
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(def))
+	}
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(def)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(def)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(def)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(def, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(def, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(def, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		var parts []string
+		if def != "" {
+			parts = strings.Split(def, ",")
+		}
+		s := reflect.MakeSlice(v.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			if err := setDefault(s.Index(i), strings.TrimSpace(part)); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+	case reflect.Ptr:
+		elem := reflect.New(v.Type().Elem())
+		if err := setDefault(elem.Elem(), def); err != nil {
+			return err
+		}
+		v.Set(elem)
+	default:
+		return fmt.Errorf("no default for %s", v.Type())
+	}
+	return nil
+}
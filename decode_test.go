@@ -0,0 +1,116 @@
+//go:build go1.18
+// +build go1.18
+
+package pkgs
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type pageStyle int
+
+const (
+	pageOffset pageStyle = iota
+	pageCursor
+)
+
+func (s *pageStyle) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "offset":
+		*s = pageOffset
+	case "cursor":
+		*s = pageCursor
+	default:
+		return fmt.Errorf("unknown page style %s", text)
+	}
+	return nil
+}
+
+type pagerData struct {
+	Size    int           `default:"20"`
+	Max     int           `default:"100"`
+	Timeout time.Duration `default:"30s"`
+	Style   pageStyle     `default:"offset"`
+	Tags    []string      `default:"a, b"`
+	Debug   bool
+}
+
+type queryOptions struct {
+	Order string `default:"asc"`
+	Limit uint   `default:"10"`
+}
+
+type fieldOptions struct {
+	Min int
+	Max int `default:"64"`
+}
+
+func TestDecodeOptions(t *testing.T) {
+
+	Convey("Decode tool options of fixture decode package", t, func() {
+		pkg := NewPackage("fixture/decode")
+
+		data, err := DecodeTool[pagerData](pkg, "pager")
+		So(err, ShouldBeNil)
+		So(data, ShouldResemble, pagerData{
+			Size:    50,
+			Max:     100,
+			Timeout: 90 * time.Second,
+			Style:   pageCursor,
+			Tags:    []string{"a", "b"},
+		})
+
+		data, err = DecodeTool[pagerData](pkg, "missing")
+		So(err, ShouldBeNil)
+		So(data.Size, ShouldEqual, 20)
+		So(data.Timeout, ShouldEqual, 30*time.Second)
+
+		_, err = DecodeTool[pagerData](pkg, "broken")
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "sizes")
+
+		pager := pkg.Tools["pager"]
+		query, err := DecodeTypeOptions[queryOptions](pager, "Query")
+		So(err, ShouldBeNil)
+		So(query, ShouldResemble, queryOptions{Order: "desc", Limit: 10})
+
+		queryType := pkg.StructTypes["Query"]
+		page, err := DecodeFieldOptions[fieldOptions](pager, queryType, queryType.FieldMap["Page"])
+		So(err, ShouldBeNil)
+		So(page, ShouldResemble, fieldOptions{Min: 1, Max: 64})
+	})
+
+	Convey("Replace default slices by shorter config slices", t, func() {
+		data, err := DecodeOptions[pagerData](map[string]interface{}{"tags": []interface{}{"x"}})
+		So(err, ShouldBeNil)
+		So(data.Tags, ShouldResemble, []string{"x"})
+		So(data.Size, ShouldEqual, 20)
+	})
+
+	Convey("Report bad values and defaults", t, func() {
+		_, err := DecodeOptions[pagerData](map[string]interface{}{"style": "page"})
+		So(err, ShouldNotBeNil)
+
+		_, err = DecodeOptions[pagerData](map[string]interface{}{"timeout": "soon"})
+		So(err, ShouldNotBeNil)
+
+		_, err = DecodeOptions[pagerData](map[string]interface{}{"size": "big"})
+		So(err, ShouldNotBeNil)
+
+		type badDefault struct {
+			Size int `default:"big"`
+		}
+		_, err = DecodeOptions[badDefault](nil)
+		So(err, ShouldNotBeNil)
+
+		type badDuration struct {
+			Timeout time.Duration `default:"soon"`
+		}
+		_, err = DecodeOptions[badDuration](nil)
+		So(err, ShouldNotBeNil)
+	})
+}
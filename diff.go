@@ -0,0 +1,315 @@
+package pkgs
+
+import (
+	"fmt"
+	"go/ast"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// SerializationTags are the struct tag keys whose names decide the wire format.
+// A change of the encoded name under one of these keys breaks serialized data.
+var SerializationTags = []string{"json", "xml", "yaml", "toml", "bson", "msgpack", "protobuf"}
+
+type ChangeKind int
+
+const (
+	TypeAdded ChangeKind = iota
+	TypeRemoved
+	TypeKindChanged
+	TypeChanged
+	FieldAdded
+	FieldRemoved
+	FieldTypeChanged
+	FieldTagChanged
+	DocChanged
+)
+
+var changeKindNames = [...]string{
+	TypeAdded:        "type added",
+	TypeRemoved:      "type removed",
+	TypeKindChanged:  "type kind changed",
+	TypeChanged:      "type changed",
+	FieldAdded:       "field added",
+	FieldRemoved:     "field removed",
+	FieldTypeChanged: "field type changed",
+	FieldTagChanged:  "field tag changed",
+	DocChanged:       "doc changed",
+}
+
+func (k ChangeKind) String() string {
+	if int(k) < len(changeKindNames) {
+		return changeKindNames[k]
+	}
+	return fmt.Sprintf("ChangeKind(%d)", int(k))
+}
+
+// Change is one difference between two loads of a package.
+// Field is empty for type level changes.
+type Change struct {
+	Kind  ChangeKind
+	Type  string
+	Field string
+	Old   string
+	New   string
+
+	// BreaksAPI reports the change breaks Go code using the old package
+	BreaksAPI bool
+	// BreaksData reports the change breaks data serialized by the old package
+	BreaksData bool
+}
+
+func (c *Change) String() string {
+	name := c.Type
+	if c.Field != "" {
+		name += "." + c.Field
+	}
+	s := fmt.Sprintf("%s: %s", name, c.Kind)
+	if c.Old != "" || c.New != "" {
+		s += fmt.Sprintf(" (%q -> %q)", c.Old, c.New)
+	}
+	return s
+}
+
+type PackageDiff struct {
+	Changes []*Change
+}
+
+// BreaksAPI reports whether any change breaks Go callers.
+func (d *PackageDiff) BreaksAPI() bool {
+	for _, c := range d.Changes {
+		if c.BreaksAPI {
+			return true
+		}
+	}
+	return false
+}
+
+// BreaksData reports whether any change breaks serialized data.
+func (d *PackageDiff) BreaksData() bool {
+	for _, c := range d.Changes {
+		if c.BreaksData {
+			return true
+		}
+	}
+	return false
+}
+
+// Breaking returns changes that break either Go callers or serialized data.
+func (d *PackageDiff) Breaking() (cs []*Change) {
+	for _, c := range d.Changes {
+		if c.BreaksAPI || c.BreaksData {
+			cs = append(cs, c)
+		}
+	}
+	return
+}
+
+// Diff compares the types of two loads of the same package.
+// Changes are ordered by type name, then by field name. Only the docs of
+// types are compared, fields have no doc in the model.
+func Diff(old, new *Package) *PackageDiff {
+	d := &PackageDiff{}
+
+	names := make(map[string]bool)
+	for _, name := range old.typeNames() {
+		names[name] = true
+	}
+	for _, name := range new.typeNames() {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	for _, name := range sorted {
+		d.diffType(old, new, name)
+	}
+	return d
+}
+
+func (d *PackageDiff) add(c *Change) {
+	d.Changes = append(d.Changes, c)
+}
+
+func (d *PackageDiff) diffType(old, new *Package, name string) {
+	exported := ast.IsExported(name)
+	oldKind, oldDoc := old.typeKind(name)
+	newKind, newDoc := new.typeKind(name)
+
+	switch {
+	case oldKind == "":
+		d.add(&Change{Kind: TypeAdded, Type: name, New: newKind})
+		return
+	case newKind == "":
+		d.add(&Change{Kind: TypeRemoved, Type: name, Old: oldKind, BreaksAPI: exported})
+		return
+	case oldKind != newKind:
+		d.add(&Change{Kind: TypeKindChanged, Type: name, Old: oldKind, New: newKind, BreaksAPI: exported, BreaksData: true})
+		return
+	}
+
+	switch oldKind {
+	case "basic":
+		o, n := old.BasicTypes[name], new.BasicTypes[name]
+		if o.Type != n.Type {
+			d.add(&Change{Kind: TypeChanged, Type: name, Old: o.Type, New: n.Type, BreaksAPI: exported, BreaksData: true})
+		}
+	case "named":
+		o, n := old.NamedTypes[name].Base.String(), new.NamedTypes[name].Base.String()
+		if o != n {
+			d.add(&Change{Kind: TypeChanged, Type: name, Old: o, New: n, BreaksAPI: exported, BreaksData: true})
+		}
+	case "struct":
+		d.diffFields(old.StructTypes[name], new.StructTypes[name])
+	case "array":
+		o, n := old.ArrayTypes[name].String(), new.ArrayTypes[name].String()
+		if o != n {
+			d.add(&Change{Kind: TypeChanged, Type: name, Old: o, New: n, BreaksAPI: exported, BreaksData: true})
+		}
+	case "map":
+		o, n := old.MapTypes[name].String(), new.MapTypes[name].String()
+		if o != n {
+			d.add(&Change{Kind: TypeChanged, Type: name, Old: o, New: n, BreaksAPI: exported, BreaksData: true})
+		}
+	}
+
+	if strings.TrimSpace(oldDoc) != strings.TrimSpace(newDoc) {
+		d.add(&Change{Kind: DocChanged, Type: name, Old: oldDoc, New: newDoc})
+	}
+}
+
+// diffFields compares both literal and promoted fields.
+func (d *PackageDiff) diffFields(old, new *Struct) {
+	exported := ast.IsExported(old.Name)
+	oldFields, newFields := old.allFields(), new.allFields()
+
+	names := make([]string, 0, len(oldFields)+len(newFields))
+	for name := range oldFields {
+		names = append(names, name)
+	}
+	for name := range newFields {
+		if _, ok := oldFields[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		o, n := oldFields[name], newFields[name]
+		switch {
+		case o == nil:
+			d.add(&Change{Kind: FieldAdded, Type: old.Name, Field: name, New: n.TypeString})
+		case n == nil:
+			d.add(&Change{
+				Kind:       FieldRemoved,
+				Type:       old.Name,
+				Field:      name,
+				Old:        o.TypeString,
+				BreaksAPI:  exported && o.Exported,
+				BreaksData: o.Exported,
+			})
+		default:
+			if o.TypeString != n.TypeString {
+				d.add(&Change{
+					Kind:       FieldTypeChanged,
+					Type:       old.Name,
+					Field:      name,
+					Old:        o.TypeString,
+					New:        n.TypeString,
+					BreaksAPI:  exported && o.Exported,
+					BreaksData: o.Exported,
+				})
+			}
+			if o.Tag != n.Tag {
+				d.add(&Change{
+					Kind:       FieldTagChanged,
+					Type:       old.Name,
+					Field:      name,
+					Old:        string(o.Tag),
+					New:        string(n.Tag),
+					BreaksData: o.Exported && !sameWireNames(name, o.Tag, n.Tag),
+				})
+			}
+		}
+	}
+}
+
+// allFields merges literal and intuitive fields by name
+func (s *Struct) allFields() map[string]*Field {
+	fields := make(map[string]*Field, len(s.FieldMap)+len(s.IntuitiveFieldMap))
+	for name, field := range s.IntuitiveFieldMap {
+		fields[name] = field
+	}
+	for name, field := range s.FieldMap {
+		fields[name] = field
+	}
+	return fields
+}
+
+// sameWireNames compares the encoded name and the ",string" option
+// of every SerializationTags key.
+func sameWireNames(field string, old, new reflect.StructTag) bool {
+	for _, key := range SerializationTags {
+		if wireName(field, old.Get(key)) != wireName(field, new.Get(key)) {
+			return false
+		}
+	}
+	return true
+}
+
+func wireName(field, value string) string {
+	parts := strings.Split(value, ",")
+	name := parts[0]
+	if name == "" {
+		name = field
+	}
+	for _, opt := range parts[1:] {
+		if opt == "string" {
+			name += ",string"
+		}
+	}
+	return name
+}
+
+func (p *Package) typeNames() (names []string) {
+	for name := range p.BasicTypes {
+		names = append(names, name)
+	}
+	for name := range p.StructTypes {
+		names = append(names, name)
+	}
+	for name := range p.ArrayTypes {
+		names = append(names, name)
+	}
+	for name := range p.MapTypes {
+		names = append(names, name)
+	}
+	for name := range p.NamedTypes {
+		names = append(names, name)
+	}
+	return
+}
+
+// typeKind returns the kind name used by Ignore, and the doc of the type
+func (p *Package) typeKind(name string) (string, string) {
+	if typ, ok := p.BasicTypes[name]; ok {
+		return "basic", typ.Doc
+	}
+	if typ, ok := p.StructTypes[name]; ok {
+		return "struct", typ.Doc
+	}
+	if typ, ok := p.ArrayTypes[name]; ok {
+		return "array", typ.Doc
+	}
+	if typ, ok := p.MapTypes[name]; ok {
+		return "map", typ.Doc
+	}
+	if typ, ok := p.NamedTypes[name]; ok {
+		return "named", typ.Doc
+	}
+	return "", ""
+}
@@ -0,0 +1,39 @@
+package pkgs
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDiff(t *testing.T) {
+
+	Convey("Diff fixture diff package", t, func() {
+		old := NewPackage("fixture/diff/old")
+		new := NewPackage("fixture/diff/new")
+
+		d := Diff(old, new)
+		So(d.BreaksAPI(), ShouldBeTrue)
+		So(d.BreaksData(), ShouldBeTrue)
+
+		var got []Change
+		for _, c := range d.Changes {
+			got = append(got, *c)
+		}
+		So(got, ShouldResemble, []Change{
+			{Kind: TypeAdded, Type: "Group", New: "struct"},
+			{Kind: TypeRemoved, Type: "Legacy", Old: "struct", BreaksAPI: true},
+			{Kind: TypeChanged, Type: "Level", Old: "int", New: "string", BreaksAPI: true, BreaksData: true},
+			{Kind: TypeChanged, Type: "Triple", Old: "[3]int", New: "[4]int", BreaksAPI: true, BreaksData: true},
+			{Kind: FieldTypeChanged, Type: "User", Field: "Age", Old: "int", New: "int64", BreaksAPI: true, BreaksData: true},
+			{Kind: FieldTagChanged, Type: "User", Field: "Email", Old: `json:"email"`, New: `json:"mail"`, BreaksData: true},
+			{Kind: FieldTagChanged, Type: "User", Field: "Name", Old: `json:"name"`, New: `json:"name" VIEW:";lmax(16)"`},
+			{Kind: FieldAdded, Type: "User", Field: "Phone", New: "string"},
+			{Kind: FieldRemoved, Type: "User", Field: "note", Old: "string"},
+			{Kind: DocChanged, Type: "User", Old: "User is a user\n", New: "User is a registered user\n"},
+			{Kind: TypeChanged, Type: "Window", Old: "[3]string", New: "[]string", BreaksAPI: true, BreaksData: true},
+		})
+
+		So(Diff(old, old).Changes, ShouldBeEmpty)
+	})
+}
@@ -0,0 +1,348 @@
+package pkgs
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+)
+
+const directivePrefix = "//pkgs:"
+
+// Directive is a comment annotation on a type or a field, like:
+//
+//	//pkgs:gen tool=validator preset=strict max=32
+//
+// It is merged into Tools[Tool].Types[Type] before the options are processed.
+// A field directive goes to Types[Type].fields[Field]. Config file values
+// take precedence over directive values: maps are merged key by key with
+// the config winning, any other config value replaces the directive value.
+type Directive struct {
+	Name   string
+	Type   string
+	Field  string
+	Tool   string
+	Preset string
+	Args   map[string]interface{}
+	Pos    token.Position
+}
+
+// knownDirectives are the supported directive names
+var knownDirectives = map[string]bool{
+	"gen": true,
+}
+
+// DirectiveError is a malformed directive at Pos
+type DirectiveError struct {
+	Pos token.Position
+	Err error
+}
+
+func (e *DirectiveError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pos, e.Err)
+}
+
+// DirectiveErrors are all the malformed directives of a package, in
+// source order
+type DirectiveErrors []*DirectiveError
+
+func (errs DirectiveErrors) Error() string {
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// parseDirectives collects directives from type and field comments. The
+// malformed ones are returned as DirectiveErrors.
+func (p *Package) parseDirectives(fs *token.FileSet, astFiles []*ast.File) error {
+	var errs DirectiveErrors
+	for _, file := range astFiles {
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				ts := spec.(*ast.TypeSpec)
+				doc := ts.Doc
+				if doc == nil && len(gen.Specs) == 1 {
+					doc = gen.Doc
+				}
+				errs = p.addDirectives(fs, ts.Name.Name, "", doc, errs)
+
+				st, ok := ts.Type.(*ast.StructType)
+				if !ok {
+					continue
+				}
+				for _, field := range st.Fields.List {
+					for _, name := range astFieldNames(field) {
+						errs = p.addDirectives(fs, ts.Name.Name, name, field.Doc, errs)
+						errs = p.addDirectives(fs, ts.Name.Name, name, field.Comment, errs)
+					}
+				}
+			}
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+func (p *Package) addDirectives(fs *token.FileSet, typ, field string, cg *ast.CommentGroup, errs DirectiveErrors) DirectiveErrors {
+	if cg == nil {
+		return errs
+	}
+	for _, c := range cg.List {
+		if !strings.HasPrefix(c.Text, directivePrefix) {
+			continue
+		}
+		pos := fs.Position(c.Pos())
+		d, err := parseDirective(c.Text[len(directivePrefix):])
+		if err != nil {
+			errs = append(errs, &DirectiveError{Pos: pos, Err: err})
+			continue
+		}
+		d.Type, d.Field, d.Pos = typ, field, pos
+		p.Directives = append(p.Directives, d)
+	}
+	return errs
+}
+
+// astFieldNames returns the declared names, or the type name of an embedded field
+func astFieldNames(field *ast.Field) (names []string) {
+	for _, name := range field.Names {
+		names = append(names, name.Name)
+	}
+	if len(field.Names) == 0 {
+		expr := field.Type
+		if star, ok := expr.(*ast.StarExpr); ok {
+			expr = star.X
+		}
+		switch t := expr.(type) {
+		case *ast.Ident:
+			names = append(names, t.Name)
+		case *ast.SelectorExpr:
+			names = append(names, t.Sel.Name)
+		}
+	}
+	return
+}
+
+// parseDirective parses "gen tool=validator preset=strict key=value flag".
+// Values are booleans, numbers, Go quoted strings or bare strings.
+// A key without value is true.
+func parseDirective(text string) (*Directive, error) {
+	words, err := splitDirective(text)
+	if err != nil {
+		return nil, err
+	}
+	if len(words) == 0 || !knownDirectives[words[0]] {
+		return nil, errors.New("unknown directive: " + directivePrefix + text)
+	}
+
+	d := &Directive{Name: words[0], Args: make(map[string]interface{})}
+	for _, word := range words[1:] {
+		key, value := word, ""
+		hasValue := false
+		if i := strings.Index(word, "="); i != -1 {
+			key, value, hasValue = word[:i], word[i+1:], true
+		}
+		if key == "" {
+			return nil, errors.New("empty key in directive: " + word)
+		}
+		switch key {
+		case "tool":
+			d.Tool = directiveString(value)
+		case "preset":
+			d.Preset = directiveString(value)
+		default:
+			if !hasValue {
+				d.Args[key] = true
+			} else {
+				d.Args[key] = directiveValue(value)
+			}
+		}
+	}
+	if d.Tool == "" {
+		return nil, errors.New("directive requires tool: " + directivePrefix + text)
+	}
+	return d, nil
+}
+
+// splitDirective splits on spaces outside of double quotes
+func splitDirective(text string) (words []string, err error) {
+	var (
+		word    []byte
+		inQuote bool
+	)
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c == '\\' && inQuote && i+1 < len(text):
+			word = append(word, c, text[i+1])
+			i++
+		case c == '"':
+			inQuote = !inQuote
+			word = append(word, c)
+		case (c == ' ' || c == '\t') && !inQuote:
+			if len(word) > 0 {
+				words = append(words, string(word))
+				word = nil
+			}
+		default:
+			word = append(word, c)
+		}
+	}
+	if inQuote {
+		return nil, errors.New("unterminated quote in directive: " + text)
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	return
+}
+
+func directiveString(value string) string {
+	if s, err := strconv.Unquote(value); err == nil {
+		return s
+	}
+	return value
+}
+
+// directiveValue decodes a value the way json5 does: numbers are float64
+func directiveValue(value string) interface{} {
+	if s, err := strconv.Unquote(value); err == nil {
+		return s
+	}
+	if b, err := strconv.ParseBool(value); err == nil {
+		return b
+	}
+	if f, err := strconv.ParseFloat(value, 64); err == nil {
+		return f
+	}
+	return value
+}
+
+// mergeDirectives merges all directives into Tools.
+func (p *Package) mergeDirectives() {
+	if len(p.Directives) == 0 {
+		return
+	}
+	if p.Tools == nil {
+		p.Tools = make(map[string]*JsonOptions)
+	}
+
+	// directive values of every tool, built before merging with config
+	gens := make(map[string]map[string]interface{})
+	for _, d := range p.Directives {
+		opt, ok := p.Tools[d.Tool]
+		if !ok {
+			opt = &JsonOptions{Command: d.Tool}
+			p.Tools[d.Tool] = opt
+		}
+		if gens[d.Tool] == nil {
+			gens[d.Tool] = make(map[string]interface{})
+		}
+		types := gens[d.Tool]
+
+		value := d.value(opt)
+		if d.Field == "" {
+			types[d.Type] = mergeOption(types[d.Type], value)
+			continue
+		}
+
+		typOpt, ok := types[d.Type].(map[string]interface{})
+		if !ok {
+			typOpt = make(map[string]interface{})
+			types[d.Type] = typOpt
+		}
+		fields, ok := typOpt[typeFieldsKey].(map[string]interface{})
+		if !ok {
+			fields = make(map[string]interface{})
+			typOpt[typeFieldsKey] = fields
+		}
+		fields[d.Field] = mergeOption(fields[d.Field], value)
+	}
+
+	for tool, types := range gens {
+		opt := p.Tools[tool]
+		if opt.Types == nil {
+			opt.Types = make(map[string]interface{})
+		}
+		for typ, value := range types {
+			if cfg, ok := opt.Types[typ]; ok {
+				value = mergeOption(value, cfg)
+			}
+			opt.Types[typ] = value
+		}
+	}
+}
+
+// value returns the option value of the directive, the args extend the
+// preset if any. Presets are resolved with the config by process, only
+// their existence is checked here.
+func (d *Directive) value(opt *JsonOptions) interface{} {
+	if d.Preset == "" {
+		return d.Args
+	}
+	if _, err := opt.preset(d.Preset); err != nil {
+		log.WithFields(logrus.Fields{
+			"pos":    d.Pos.String(),
+			"preset": d.Preset,
+			"error":  err,
+		}).Fatal("Preset not resolved")
+	}
+	value := map[string]interface{}{presetExtends: "&" + d.Preset}
+	for k, v := range d.Args {
+		value[k] = v
+	}
+	return value
+}
+
+// mergeOption merges over into base. Maps are merged recursively with over
+// winning, any other over value replaces base. The result is a deep copy,
+// it shares no map or array with base and over.
+func mergeOption(base, over interface{}) interface{} {
+	bm, ok := base.(map[string]interface{})
+	if !ok {
+		return copyOption(over)
+	}
+	om, ok := over.(map[string]interface{})
+	if !ok {
+		return copyOption(over)
+	}
+	merged := make(map[string]interface{}, len(bm)+len(om))
+	for k, v := range bm {
+		merged[k] = copyOption(v)
+	}
+	for k, v := range om {
+		merged[k] = mergeOption(merged[k], v)
+	}
+	return merged
+}
+
+// copyOption deep copies the maps and arrays of an option
+func copyOption(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[k] = copyOption(e)
+		}
+		return m
+	case []interface{}:
+		a := make([]interface{}, len(v))
+		for i, e := range v {
+			a[i] = copyOption(e)
+		}
+		return a
+	}
+	return v
+}
@@ -0,0 +1,91 @@
+package pkgs
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDirective(t *testing.T) {
+
+	Convey("Parse directives", t, func() {
+		d, err := parseDirective(`gen tool=validator preset=strict max=32 flag label="a b"`)
+		So(err, ShouldBeNil)
+		So(d.Name, ShouldEqual, "gen")
+		So(d.Tool, ShouldEqual, "validator")
+		So(d.Preset, ShouldEqual, "strict")
+		So(d.Args, ShouldResemble, map[string]interface{}{
+			"max":   float64(32),
+			"flag":  true,
+			"label": "a b",
+		})
+
+		_, err = parseDirective("nogen tool=validator")
+		So(err.Error(), ShouldEqual, "unknown directive: //pkgs:nogen tool=validator")
+
+		_, err = parseDirective("gen max=1")
+		So(err, ShouldNotBeNil)
+
+		_, err = parseDirective(`gen tool=validator label="a b`)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Merge directives of fixture directive package", t, func() {
+		pkg := NewPackage("fixture/directive")
+		So(len(pkg.Directives), ShouldEqual, 4)
+
+		d := pkg.Directives[0]
+		So(d.Type, ShouldEqual, "Account")
+		So(d.Field, ShouldEqual, "")
+		So(strings.HasSuffix(d.Pos.Filename, "directive.go"), ShouldBeTrue)
+		So(d.Pos.Line, ShouldEqual, 4)
+
+		So(pkg.StructTypes["Account"].Doc, ShouldEqual, "Account is an account\n")
+
+		validator := pkg.Tools["validator"]
+		So(validator.Types["Account"], ShouldResemble, map[string]interface{}{
+			"min": float64(2),
+			"max": float64(32),
+			"fields": map[string]interface{}{
+				"Name":  map[string]interface{}{"required": true},
+				"Email": map[string]interface{}{"format": "email address"},
+			},
+		})
+
+		docs := pkg.Tools["docs"]
+		So(docs, ShouldNotBeNil)
+		So(docs.Command, ShouldEqual, "docs")
+		So(docs.Types["Accounts"], ShouldResemble, map[string]interface{}{})
+	})
+
+	Convey("Return bad directives with positions", t, func() {
+		src := `package bad
+
+//pkgs:gen max=1
+type Account struct {
+	Name string //pkgs:nogen tool=validator
+	//pkgs:gen tool=validator required
+	Email string
+}
+`
+		fs := token.NewFileSet()
+		file, err := parser.ParseFile(fs, "bad.go", src, parser.ParseComments)
+		So(err, ShouldBeNil)
+
+		p := &Package{}
+		err = p.parseDirectives(fs, []*ast.File{file})
+		errs, ok := err.(DirectiveErrors)
+		So(ok, ShouldBeTrue)
+		So(errs, ShouldHaveLength, 2)
+		So(errs[0].Pos.Line, ShouldEqual, 3)
+		So(errs[1].Pos.Line, ShouldEqual, 5)
+		So(errs[1].Error(), ShouldEqual, "bad.go:5:14: unknown directive: //pkgs:nogen tool=validator")
+
+		So(p.Directives, ShouldHaveLength, 1)
+		So(p.Directives[0].Field, ShouldEqual, "Email")
+	})
+}
@@ -0,0 +1,191 @@
+package pkgs
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"sort"
+)
+
+// ModelVersion is the version of the JSON encoding of Package.
+// It is increased on every incompatible change of the encoding.
+const ModelVersion = 1
+
+// jsonPackage is the JSON encoding of Package. The go/types and go/doc
+// values are not encoded, TypeRef and Pos carry their information.
+type jsonPackage struct {
+	Version    int
+	Dir        string
+	Name       string
+	Dst        string
+	Arch       string                  `json:",omitempty"`
+	Tools      map[string]*JsonOptions `json:",omitempty"`
+	Directives []*Directive            `json:",omitempty"`
+	Basics     []*Basic
+	Structs    []*jsonStruct
+	Arrays     []*Array
+	Maps       []*Map
+	Nameds     []*Named `json:",omitempty"`
+	Funcs      []*Func  `json:",omitempty"`
+	Vars       []*Var   `json:",omitempty"`
+	Consts     []*Const `json:",omitempty"`
+}
+
+type jsonStruct struct {
+	Name            string
+	Doc             string
+	Pos             token.Position
+	Size            int64
+	Align           int64
+	OptimalSize     int64
+	Fields          []*Field
+	IntuitiveFields []*Field
+	Constructors    []string `json:",omitempty"`
+}
+
+// MarshalJSON encodes the model with ModelVersion. Types are sorted by name.
+func (p *Package) MarshalJSON() ([]byte, error) {
+	jp := &jsonPackage{
+		Version:    ModelVersion,
+		Dir:        p.Dir,
+		Name:       p.Name,
+		Dst:        p.Dst,
+		Arch:       p.Arch,
+		Tools:      p.Tools,
+		Directives: p.Directives,
+		Basics:     []*Basic{},
+		Structs:    []*jsonStruct{},
+		Arrays:     []*Array{},
+		Maps:       []*Map{},
+	}
+	names := p.typeNames()
+	sort.Strings(names)
+	for _, name := range names {
+		if typ, ok := p.BasicTypes[name]; ok {
+			jp.Basics = append(jp.Basics, typ)
+		} else if s, ok := p.StructTypes[name]; ok {
+			js := &jsonStruct{
+				Name:            s.Name,
+				Doc:             s.Doc,
+				Pos:             s.Pos,
+				Size:            s.Size,
+				Align:           s.Align,
+				OptimalSize:     s.OptimalSize,
+				Fields:          s.Fields,
+				IntuitiveFields: s.IntuitiveFields,
+			}
+			for _, fn := range s.Constructors {
+				js.Constructors = append(js.Constructors, fn.Name)
+			}
+			jp.Structs = append(jp.Structs, js)
+		} else if typ, ok := p.ArrayTypes[name]; ok {
+			jp.Arrays = append(jp.Arrays, typ)
+		} else if typ, ok := p.MapTypes[name]; ok {
+			jp.Maps = append(jp.Maps, typ)
+		} else if typ, ok := p.NamedTypes[name]; ok {
+			jp.Nameds = append(jp.Nameds, typ)
+		}
+	}
+	for _, name := range sortedFuncNames(p.Funcs) {
+		jp.Funcs = append(jp.Funcs, p.Funcs[name])
+	}
+	vars := make([]string, 0, len(p.Vars))
+	for name := range p.Vars {
+		vars = append(vars, name)
+	}
+	sort.Strings(vars)
+	for _, name := range vars {
+		jp.Vars = append(jp.Vars, p.Vars[name])
+	}
+	consts := make([]string, 0, len(p.Consts))
+	for name := range p.Consts {
+		consts = append(consts, name)
+	}
+	sort.Strings(consts)
+	for _, name := range consts {
+		jp.Consts = append(jp.Consts, p.Consts[name])
+	}
+	return json.Marshal(jp)
+}
+
+// UnmarshalJSON decodes a model encoded by MarshalJSON. TypesPkg, Doc,
+// Struct.Underline and the types.Type of fields are not restored.
+func (p *Package) UnmarshalJSON(data []byte) error {
+	var jp jsonPackage
+	if err := json.Unmarshal(data, &jp); err != nil {
+		return err
+	}
+	if jp.Version != ModelVersion {
+		return fmt.Errorf("unsupported model version %d, want %d", jp.Version, ModelVersion)
+	}
+
+	*p = Package{
+		Dir:         jp.Dir,
+		Name:        jp.Name,
+		Dst:         jp.Dst,
+		Arch:        jp.Arch,
+		Tools:       jp.Tools,
+		Directives:  jp.Directives,
+		BasicTypes:  make(map[string]*Basic),
+		StructTypes: make(map[string]*Struct),
+		ArrayTypes:  make(map[string]*Array),
+		MapTypes:    make(map[string]*Map),
+		NamedTypes:  make(map[string]*Named),
+		Funcs:       make(map[string]*Func),
+		Vars:        make(map[string]*Var),
+		Consts:      make(map[string]*Const),
+	}
+	for _, fn := range jp.Funcs {
+		p.Funcs[fn.Name] = fn
+	}
+	for _, vr := range jp.Vars {
+		p.Vars[vr.Name] = vr
+	}
+	for _, c := range jp.Consts {
+		p.Consts[c.Name] = c
+	}
+	for _, typ := range jp.Basics {
+		p.BasicTypes[typ.Name] = typ
+	}
+	for _, js := range jp.Structs {
+		s := &Struct{
+			Name:              js.Name,
+			Doc:               js.Doc,
+			Pos:               js.Pos,
+			Size:              js.Size,
+			Align:             js.Align,
+			OptimalSize:       js.OptimalSize,
+			Fields:            js.Fields,
+			FieldMap:          make(map[string]*Field),
+			IntuitiveFieldMap: make(map[string]*Field),
+			Pkg:               p,
+		}
+		for _, field := range s.Fields {
+			s.FieldMap[field.Name] = field
+		}
+		// literal fields are shared by Fields and IntuitiveFields
+		for _, field := range js.IntuitiveFields {
+			if literal, ok := s.FieldMap[field.Name]; ok {
+				field = literal
+			}
+			s.IntuitiveFields = append(s.IntuitiveFields, field)
+			s.IntuitiveFieldMap[field.Name] = field
+		}
+		for _, name := range js.Constructors {
+			if fn, ok := p.Funcs[name]; ok {
+				s.Constructors = append(s.Constructors, fn)
+			}
+		}
+		p.StructTypes[s.Name] = s
+	}
+	for _, typ := range jp.Arrays {
+		p.ArrayTypes[typ.Name] = typ
+	}
+	for _, typ := range jp.Maps {
+		p.MapTypes[typ.Name] = typ
+	}
+	for _, typ := range jp.Nameds {
+		p.NamedTypes[typ.Name] = typ
+	}
+	return nil
+}
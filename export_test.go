@@ -0,0 +1,61 @@
+package pkgs
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestExport(t *testing.T) {
+
+	Convey("Export and import fixture foo package", t, func() {
+		files, err := filepath.Glob("./fixture/foo/*.go")
+		So(err, ShouldBeNil)
+		pkg := NewPackage(files...)
+
+		data, err := json.Marshal(pkg)
+		So(err, ShouldBeNil)
+
+		var imported Package
+		So(json.Unmarshal(data, &imported), ShouldBeNil)
+		So(imported.Name, ShouldEqual, "foo")
+		So(imported.Tools["tagsjson"].Types["Foo"], ShouldResemble, pkg.Tools["tagsjson"].Types["Foo"])
+
+		bobTyp := imported.StructTypes["Bob"]
+		So(bobTyp, ShouldNotBeNil)
+		So(bobTyp.Pkg, ShouldEqual, &imported)
+		So(bobTyp.Doc, ShouldEqual, pkg.StructTypes["Bob"].Doc)
+		So(bobTyp.Pos, ShouldResemble, pkg.StructTypes["Bob"].Pos)
+		So(len(bobTyp.Fields), ShouldEqual, 3)
+		So(len(bobTyp.IntuitiveFields), ShouldEqual, 5)
+		So(bobTyp.IntuitiveFieldMap["Name"], ShouldEqual, bobTyp.FieldMap["Name"])
+
+		fooField := bobTyp.FieldMap["Foo"]
+		So(fooField.TypeString, ShouldEqual, "*Foo")
+		So(fooField.Ref.String(), ShouldEqual, "*Foo")
+		So(bobTyp.FieldMap["Model"].Ref.String(), ShouldEqual, "github.com/jinzhu/gorm.Model")
+		So(bobTyp.FieldMap["Name"].Tag.Get("MGR"), ShouldEqual, ";lmax(16)")
+
+		ps := imported.StructTypes["Alice"].ComputePkgTagPaths("VIEW")
+		So(stripFields(ps), ShouldResemble, stripFields(pkg.StructTypes["Alice"].ComputePkgTagPaths("VIEW")))
+
+		box := pkg.StructTypes["Box"]
+		So(box.FieldMap["Value"].Ref, ShouldResemble, &TypeRef{Kind: "interface", Name: "interface{}"})
+		So(box.FieldMap["Labels"].Ref.String(), ShouldEqual, "map[string]string")
+		So(pkg.MapTypes["Labels"], ShouldBeNil)
+		for name, field := range box.FieldMap {
+			So(imported.StructTypes["Box"].FieldMap[name].Ref, ShouldResemble, field.Ref)
+		}
+
+		So(imported.MapTypes["BobsMap"], ShouldResemble, pkg.MapTypes["BobsMap"])
+		So(imported.ArrayTypes["Boyss"], ShouldResemble, pkg.ArrayTypes["Boyss"])
+
+		again, err := json.Marshal(&imported)
+		So(err, ShouldBeNil)
+		So(string(again), ShouldEqual, string(data))
+
+		So(json.Unmarshal([]byte(`{"Version":0}`), &imported), ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,134 @@
+package pkgs
+
+import (
+	"fmt"
+	"sort"
+)
+
+// typeFieldsKey is the section of a Types entry with the options of the
+// struct fields, keyed by the names of IntuitiveFieldMap:
+//
+//	Types: {Bob: {max: 16, fields: {Name: {required: true}}}}
+const typeFieldsKey = "fields"
+
+// typeFields returns the fields section of a Types entry
+func typeFields(typOpt interface{}) (map[string]interface{}, bool) {
+	m, ok := typOpt.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	fields, ok := m[typeFieldsKey].(map[string]interface{})
+	return fields, ok
+}
+
+// validateFields checks the fields sections of the expanded Types. The
+// fields must be intuitive fields of the struct, promoted ones included,
+// and their options maps.
+func (opt *JsonOptions) validateFields(p *Package) error {
+	names := make([]string, 0, len(opt.Types))
+	for name := range opt.Types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		m, ok := opt.Types[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		section, ok := m[typeFieldsKey]
+		if !ok {
+			continue
+		}
+		fields, ok := section.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s.%s must be a map", name, typeFieldsKey)
+		}
+		s, ok := p.StructTypes[name]
+		if !ok {
+			return fmt.Errorf("%s has %s but is not a struct", name, typeFieldsKey)
+		}
+		for field, option := range fields {
+			if _, ok := s.IntuitiveFieldMap[field]; !ok {
+				return fmt.Errorf("%s.%s: unknown field %s", name, typeFieldsKey, field)
+			}
+			if _, ok := option.(map[string]interface{}); !ok {
+				return fmt.Errorf("%s.%s.%s must be a map", name, typeFieldsKey, field)
+			}
+		}
+	}
+	return nil
+}
+
+// pruneFields drops the fields the type does not have from the option of
+// a pattern key, so "*" can set options of the fields where they exist.
+// option is not modified.
+func (p *Package) pruneFields(name string, option interface{}) interface{} {
+	fields, ok := typeFields(option)
+	if !ok {
+		return option
+	}
+	pruned := make(map[string]interface{}, len(option.(map[string]interface{})))
+	for k, v := range option.(map[string]interface{}) {
+		pruned[k] = v
+	}
+	s, ok := p.StructTypes[name]
+	if !ok {
+		delete(pruned, typeFieldsKey)
+		return pruned
+	}
+	own := make(map[string]interface{}, len(fields))
+	for field, v := range fields {
+		if _, ok := s.IntuitiveFieldMap[field]; ok {
+			own[field] = v
+		}
+	}
+	pruned[typeFieldsKey] = own
+	return pruned
+}
+
+// FieldOptions returns the options of field, an intuitive field of struct
+// s or the field of the struct declaring it, like the Leaf of a TagPath.
+// For a promoted field, the options of the embedded structs are merged
+// from the declaring struct up to s, the outer ones winning. It returns
+// false if no struct on the way has options for the field. The result is
+// a copy, callers may modify it.
+func (opt *JsonOptions) FieldOptions(s *Struct, field *Field) (map[string]interface{}, bool) {
+	name := field.Name
+	promoted, ok := s.promotedFields(name)
+	if s.IntuitiveFieldMap[name] != field && (!ok || promoted[len(promoted)-1] != field) {
+		return nil, false
+	}
+
+	// s and the local embedded structs declaring or promoting the field,
+	// outermost first
+	owners := []string{s.Name}
+	if ok {
+		owner := s
+		for _, hop := range promoted[:len(promoted)-1] {
+			var sub *Struct
+			if _, sub, ok = owner.Pkg.fieldStruct(hop); !ok {
+				break
+			}
+			if sub.Pkg == s.Pkg {
+				owners = append(owners, sub.Name)
+			}
+			owner = sub
+		}
+	}
+
+	var merged interface{}
+	found := false
+	for i := len(owners) - 1; i >= 0; i-- {
+		fields, ok := typeFields(opt.Types[owners[i]])
+		if !ok {
+			continue
+		}
+		if v, ok := fields[name]; ok {
+			merged = mergeOption(merged, v)
+			found = true
+		}
+	}
+	m, ok := merged.(map[string]interface{})
+	return m, found && ok
+}
@@ -0,0 +1,84 @@
+package pkgs
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFieldOptions(t *testing.T) {
+
+	Convey("Merge field options of fixture fields package", t, func() {
+		pkg := NewPackage("fixture/fields")
+		validator := pkg.Tools["validator"]
+		account := pkg.StructTypes["Account"]
+
+		note, ok := validator.FieldOptions(account, account.IntuitiveFieldMap["Note"])
+		So(ok, ShouldBeTrue)
+		So(note, ShouldResemble, map[string]interface{}{"max": float64(32), "trim": true})
+
+		id, ok := validator.FieldOptions(account, account.IntuitiveFieldMap["ID"])
+		So(ok, ShouldBeTrue)
+		So(id, ShouldResemble, map[string]interface{}{"readonly": true, "min": float64(1)})
+
+		name, ok := validator.FieldOptions(account, account.IntuitiveFieldMap["Name"])
+		So(ok, ShouldBeTrue)
+		So(name, ShouldResemble, map[string]interface{}{"required": true})
+
+		// the result does not share the config
+		name["required"] = false
+		name, _ = validator.FieldOptions(account, account.IntuitiveFieldMap["Name"])
+		So(name, ShouldResemble, map[string]interface{}{"required": true})
+
+		_, ok = validator.FieldOptions(account, account.IntuitiveFieldMap["Email"])
+		So(ok, ShouldBeFalse)
+		// the field of the declaring struct, like tag path leaves
+		note, ok = validator.FieldOptions(account, pkg.StructTypes["Base"].FieldMap["Note"])
+		So(ok, ShouldBeTrue)
+		So(note, ShouldResemble, map[string]interface{}{"max": float64(32), "trim": true})
+		_, ok = validator.FieldOptions(account, &Field{Name: "Note"})
+		So(ok, ShouldBeFalse)
+
+		// "*" only sets fields where they exist
+		So(validator.Types["Level"], ShouldResemble, map[string]interface{}{})
+		So(validator.Types["Meta"], ShouldResemble, map[string]interface{}{
+			"fields": map[string]interface{}{
+				"ID":   map[string]interface{}{"readonly": true},
+				"Note": map[string]interface{}{"max": float64(32)},
+			},
+		})
+	})
+
+	Convey("Validate fields of Types entries", t, func() {
+		pkg := NewPackage("fixture/fields")
+
+		for msg, types := range map[string]map[string]interface{}{
+			"Account.fields: unknown field Missing": {
+				"Account": map[string]interface{}{"fields": map[string]interface{}{"Missing": true}},
+			},
+			"Level has fields but is not a struct": {
+				"Level": map[string]interface{}{"fields": map[string]interface{}{}},
+			},
+			"Meta.fields must be a map": {
+				"Meta": map[string]interface{}{"fields": []interface{}{"Owner"}},
+			},
+			"Account.fields.Owner must be a map": {
+				"Account": map[string]interface{}{"fields": map[string]interface{}{"Owner": true}},
+			},
+		} {
+			opt := &JsonOptions{Types: types}
+			err := opt.validateFields(pkg)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, msg)
+		}
+
+		// promoted fields are valid
+		opt := &JsonOptions{Types: map[string]interface{}{
+			"Account": map[string]interface{}{"fields": map[string]interface{}{
+				"Owner": map[string]interface{}{},
+				"Note":  map[string]interface{}{"trim": true},
+			}},
+		}}
+		So(opt.validateFields(pkg), ShouldBeNil)
+	})
+}
@@ -0,0 +1,32 @@
+package accessor
+
+import "crypto/x509/pkix"
+
+type Meta struct {
+	Owner string `ACC:"owner"`
+}
+
+type Profile struct {
+	*Meta
+	Nick string `ACC:"nick"`
+}
+
+type Bar struct {
+	Count int `ACC:"count"`
+	Next  *Bar
+}
+
+type Foo struct {
+	Bar  *Bar
+	Name string `ACC:"name"`
+}
+
+type Alice struct {
+	Foo     *Foo
+	Profile Profile
+	Foos    []Foo
+}
+
+type Cert struct {
+	Alg *pkix.AlgorithmIdentifier
+}
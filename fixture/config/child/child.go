@@ -0,0 +1,5 @@
+package child
+
+type Query struct {
+	Page int
+}
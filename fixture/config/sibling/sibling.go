@@ -0,0 +1,5 @@
+package sibling
+
+type Sibling struct {
+	Name string
+}
@@ -0,0 +1,21 @@
+package consts
+
+type Level int
+
+// Levels
+const (
+	Debug Level = iota
+	Info
+)
+
+// DefaultPageSize is the page size when not specified
+const DefaultPageSize = 20
+
+const (
+	MaxSize  int64      = 1 << 40
+	Ratio               = 1.0 / 3
+	Greeting            = "hello"
+	enabled             = true
+	Big                 = 1 << 70
+	Wave     complex128 = 1 + 2i
+)
@@ -0,0 +1,14 @@
+package cross
+
+import (
+	"crypto/x509/pkix"
+	"time"
+)
+
+type Cert struct {
+	Name    string `asn1:"utf8"`
+	Alg     pkix.AlgorithmIdentifier
+	Algs    []*pkix.AlgorithmIdentifier
+	Subject map[string]pkix.AttributeTypeAndValueSET
+	Created time.Time
+}
@@ -0,0 +1,6 @@
+package decode
+
+type Query struct {
+	Page int
+	Size int
+}
@@ -0,0 +1,22 @@
+package diff
+
+// User is a registered user
+type User struct {
+	ID    uint
+	Name  string `json:"name" VIEW:";lmax(16)"`
+	Email string `json:"mail"`
+	Age   int64  `json:"age"`
+	Phone string `json:"phone"`
+}
+
+type Level string
+
+type Users []*User
+
+type Triple [4]int
+
+type Window []string
+
+type Group struct {
+	Users Users
+}
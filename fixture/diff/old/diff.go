@@ -0,0 +1,22 @@
+package diff
+
+// User is a user
+type User struct {
+	ID    uint
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Age   int    `json:"age"`
+	note  string
+}
+
+type Level int
+
+type Users []*User
+
+type Triple [3]int
+
+type Window [3]string
+
+type Legacy struct {
+	ID uint
+}
@@ -0,0 +1,12 @@
+package directive
+
+// Account is an account
+//pkgs:gen tool=validator preset=strict max=32
+type Account struct {
+	//pkgs:gen tool=validator required
+	Name  string
+	Email string //pkgs:gen tool=validator format="email address"
+}
+
+//pkgs:gen tool=docs
+type Accounts []*Account
@@ -0,0 +1,19 @@
+package fields
+
+type Base struct {
+	ID   uint
+	Note string
+}
+
+type Meta struct {
+	*Base
+	Owner string
+}
+
+type Account struct {
+	Meta
+	Name  string
+	Email string
+}
+
+type Level int
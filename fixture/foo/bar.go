@@ -14,3 +14,12 @@ type IBar interface {
 }
 
 type Sbar *Bar
+
+type Labels = map[string]string
+
+// Box holds a value of any type
+type Box struct {
+	Value  any
+	Labels Labels
+	Point  struct{ X, Y int }
+}
@@ -0,0 +1,11 @@
+package yaml
+
+type Foo struct {
+	Name string
+}
+
+type Bar struct {
+	ID uint
+}
+
+type Count int
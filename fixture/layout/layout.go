@@ -0,0 +1,27 @@
+package layout
+
+type Hot struct {
+	A bool
+	B int64
+	C bool
+	D int32
+	Inner
+	*Cold
+}
+
+type Inner struct {
+	X int16
+	Y bool
+}
+
+type Cold struct {
+	Z int64
+}
+
+type Blank struct {
+	A bool
+	_ [3]byte
+	B int32
+	_ [4]byte
+	C int64
+}
@@ -0,0 +1,29 @@
+package named
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Stamp is a point in time
+type Stamp time.Time
+
+type Zone time.Location
+
+type Ints sort.IntSlice
+
+type Counts map[string]int
+
+type Totals Counts
+
+type Stringer fmt.Stringer
+
+type Timeout time.Duration
+
+type Seconds int
+
+type Event struct {
+	At      Stamp
+	Timeout Timeout
+}
@@ -0,0 +1,21 @@
+package patterns
+
+type UserDto struct {
+	Name string `json:"name"`
+}
+
+type OrderDto struct {
+	ID uint
+}
+
+type Bob struct {
+	Name string `json:"name"`
+}
+
+type Bobby struct {
+	Age int
+}
+
+type Level int
+
+type Levels []Level
@@ -0,0 +1,5 @@
+package plain
+
+type Plain struct {
+	Name string
+}
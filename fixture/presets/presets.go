@@ -0,0 +1,13 @@
+package presets
+
+type Account struct {
+	Name string
+}
+
+type Profile struct {
+	Nick string
+}
+
+type User struct {
+	Email string
+}
@@ -0,0 +1,5 @@
+package strict
+
+type Foo struct {
+	Name string
+}
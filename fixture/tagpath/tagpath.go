@@ -0,0 +1,65 @@
+package tagpath
+
+type Address struct {
+	Street string `VIEW:";lmax(64)" json:"street"`
+	City   string `VIEW:"city" MGR:"-" json:"city"`
+}
+
+type Item struct {
+	Title string `VIEW:";lmin(1)" json:"title"`
+	Price int    `MGR:"price" json:"price"`
+}
+
+type Node struct {
+	Name     string `VIEW:"name"`
+	Children []Node
+}
+
+type Items []*Item
+
+type Order struct {
+	ID      uint
+	Items   []Item              `json:"items"`
+	Ptrs    Items               `json:"ptrs"`
+	Labels  map[string]*Address `json:"labels"`
+	Matrix  [][]Item            `json:"-"`
+	Root    *Node
+	Address Address `json:"address"`
+}
+
+type Audit struct {
+	By string `VIEW:"by" json:"by"`
+}
+
+type Stamp struct {
+	At int64 `VIEW:"at" json:"at"`
+}
+
+type Event struct {
+	*Audit
+	Stamp `json:"stamp"`
+	Kind  string `VIEW:"kind" json:"kind"`
+}
+
+type Left struct {
+	Name string `VIEW:"left"`
+	Note string `VIEW:"note"`
+}
+
+type Right struct {
+	Name string `VIEW:"right"`
+	Deep
+}
+
+type Deep struct {
+	Note string `VIEW:"deep"`
+	Name string `VIEW:"deep"`
+	Code string `VIEW:"code"`
+}
+
+// Both has the conflicting Name at depth 1, dropped at every depth
+type Both struct {
+	Left
+	*Right
+	Own string `VIEW:"own"`
+}
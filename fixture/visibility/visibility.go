@@ -0,0 +1,33 @@
+package visibility
+
+type Public struct {
+	embedded
+	ID     int
+	secret string
+	Inner  *inner
+	Items  []item
+}
+
+type embedded struct {
+	Y int
+}
+
+type inner struct {
+	X int
+}
+
+type item struct {
+	Z int
+}
+
+type Labels map[string]*label
+
+type label struct {
+	Text string
+}
+
+type hidden struct {
+	H int
+}
+
+type notes []string
@@ -0,0 +1,46 @@
+package wiring
+
+import "errors"
+
+type Bob struct {
+	Name string
+}
+
+// DefaultName is used by NewBob
+var DefaultName = "bob"
+
+var registry = map[string]*Bob{}
+
+var ErrNoName error
+
+// NewBob creates a Bob
+func NewBob(name string) (*Bob, error) {
+	if name == "" {
+		return nil, errors.New("empty name")
+	}
+	return &Bob{Name: name}, nil
+}
+
+func newBobValue() Bob {
+	return Bob{Name: DefaultName}
+}
+
+func NewBobDefault() Bob {
+	return newBobValue()
+}
+
+type Letter struct {
+	To string
+}
+
+// Newsletter is not a constructor of Letter
+func Newsletter() *Letter {
+	return &Letter{To: "all"}
+}
+
+// Register adds bobs to the registry
+func Register(bobs ...*Bob) {
+	for _, bob := range bobs {
+		registry[bob.Name] = bob
+	}
+}
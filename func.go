@@ -0,0 +1,124 @@
+package pkgs
+
+import (
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type Param struct {
+	Name       string
+	TypeString string
+	Ref        *TypeRef
+}
+
+func newParams(tuple *types.Tuple, p *Package) (params []*Param) {
+	for i := 0; i < tuple.Len(); i++ {
+		v := tuple.At(i)
+		params = append(params, &Param{
+			Name:       v.Name(),
+			TypeString: types.TypeString(v.Type(), types.RelativeTo(p.TypesPkg)),
+			Ref:        NewTypeRef(v.Type(), p.TypesPkg),
+		})
+	}
+	return
+}
+
+// Func is a package level function, methods are not included
+type Func struct {
+	Name      string
+	Doc       string
+	Pos       token.Position
+	Signature string
+	Params    []*Param
+	Results   []*Param
+	Variadic  bool
+	Exported  bool
+
+	// Constructs is the struct returned by a constructor, see linkConstructors
+	Constructs string
+}
+
+func NewFunc(obj *types.Func, p *Package) *Func {
+	sig := obj.Type().(*types.Signature)
+	return &Func{
+		Name:      obj.Name(),
+		Pos:       p.position(obj.Pos()),
+		Signature: types.TypeString(sig, types.RelativeTo(p.TypesPkg)),
+		Params:    newParams(sig.Params(), p),
+		Results:   newParams(sig.Results(), p),
+		Variadic:  sig.Variadic(),
+		Exported:  obj.Exported(),
+	}
+}
+
+// Var is a package level variable
+type Var struct {
+	Name       string
+	Doc        string
+	Pos        token.Position
+	TypeString string
+	Ref        *TypeRef
+	Exported   bool
+	// Value is the source of the initializer, empty if not initialized
+	// in the declaration
+	Value string
+}
+
+func NewVar(obj *types.Var, p *Package) *Var {
+	return &Var{
+		Name:       obj.Name(),
+		Pos:        p.position(obj.Pos()),
+		TypeString: types.TypeString(obj.Type(), types.RelativeTo(p.TypesPkg)),
+		Ref:        NewTypeRef(obj.Type(), p.TypesPkg),
+		Exported:   obj.Exported(),
+		Value:      p.varValues[obj.Name()],
+	}
+}
+
+// linkConstructors links every function named NewT, or NewT followed by
+// an upper case suffix like NewTFromFile, whose first result is T or *T
+// of a package struct T.
+func (p *Package) linkConstructors() {
+	for _, name := range sortedFuncNames(p.Funcs) {
+		fn := p.Funcs[name]
+		if !strings.HasPrefix(fn.Name, "New") || len(fn.Results) == 0 {
+			continue
+		}
+		ref := fn.Results[0].Ref.Deref()
+		if ref.Kind != "named" || ref.Pkg != "" {
+			continue
+		}
+		if !isConstructorName(fn.Name, ref.Name) {
+			continue
+		}
+		if s, ok := p.StructTypes[ref.Name]; ok {
+			fn.Constructs = s.Name
+			s.Constructors = append(s.Constructors, fn)
+		}
+	}
+}
+
+// isConstructorName reports whether name is "New"+typ, optionally followed
+// by a suffix starting with an upper case letter, so Newsletter does not
+// construct Letter.
+func isConstructorName(name, typ string) bool {
+	suffix := strings.TrimPrefix(name, "New"+typ)
+	if len(suffix) == len(name) {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(suffix)
+	return suffix == "" || unicode.IsUpper(r)
+}
+
+func sortedFuncNames(funcs map[string]*Func) []string {
+	names := make([]string, 0, len(funcs))
+	for name := range funcs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
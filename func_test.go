@@ -0,0 +1,57 @@
+package pkgs
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFuncsAndVars(t *testing.T) {
+
+	Convey("Parse funcs and vars of fixture wiring package", t, func() {
+		pkg := NewPackage("fixture/wiring")
+		So(len(pkg.Funcs), ShouldEqual, 5)
+		So(len(pkg.Vars), ShouldEqual, 3)
+
+		newBob := pkg.Funcs["NewBob"]
+		So(newBob.Doc, ShouldEqual, "NewBob creates a Bob\n")
+		So(newBob.Signature, ShouldEqual, "func(name string) (*Bob, error)")
+		So(newBob.Params[0].Name, ShouldEqual, "name")
+		So(newBob.Results[0].TypeString, ShouldEqual, "*Bob")
+		So(newBob.Pos.Line, ShouldEqual, 17)
+		So(newBob.Constructs, ShouldEqual, "Bob")
+
+		register := pkg.Funcs["Register"]
+		So(register.Doc, ShouldEqual, "Register adds bobs to the registry\n")
+		So(register.Variadic, ShouldBeTrue)
+		So(register.Params[0].TypeString, ShouldEqual, "[]*Bob")
+		So(register.Constructs, ShouldEqual, "")
+
+		So(pkg.Funcs["newBobValue"].Constructs, ShouldEqual, "")
+
+		bob := pkg.StructTypes["Bob"]
+		So(bob.Constructors, ShouldResemble, []*Func{pkg.Funcs["NewBob"], pkg.Funcs["NewBobDefault"]})
+
+		So(pkg.Funcs["Newsletter"].Constructs, ShouldEqual, "")
+		So(pkg.StructTypes["Letter"].Constructors, ShouldBeEmpty)
+
+		defaultName := pkg.Vars["DefaultName"]
+		So(defaultName.Doc, ShouldEqual, "DefaultName is used by NewBob\n")
+		So(defaultName.TypeString, ShouldEqual, "string")
+		So(defaultName.Value, ShouldEqual, `"bob"`)
+		So(pkg.Vars["registry"].Value, ShouldEqual, "map[string]*Bob{}")
+		So(pkg.Vars["ErrNoName"].Value, ShouldEqual, "")
+
+		exported := pkg.Filter(VisibilityExported)
+		So(exported.Vars["registry"], ShouldBeNil)
+		So(exported.Funcs["newBobValue"], ShouldBeNil)
+
+		data, err := json.Marshal(pkg)
+		So(err, ShouldBeNil)
+		var imported Package
+		So(json.Unmarshal(data, &imported), ShouldBeNil)
+		So(imported.StructTypes["Bob"].Constructors[0], ShouldEqual, imported.Funcs["NewBob"])
+		So(imported.Vars["DefaultName"], ShouldResemble, defaultName)
+	})
+}
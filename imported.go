@@ -0,0 +1,97 @@
+package pkgs
+
+import (
+	"go/importer"
+	"go/types"
+
+	"github.com/Sirupsen/logrus"
+)
+
+// Imported returns the model of an imported package, built from go/types
+// on first use and shared by all packages reached from p. The model has
+// no doc and no config, and structs are added on demand by ImportedStruct.
+func (p *Package) Imported(tp *types.Package) *Package {
+	if p.imports == nil {
+		p.imports = make(map[string]*Package)
+	}
+	if ip, ok := p.imports[tp.Path()]; ok {
+		return ip
+	}
+	ip := &Package{
+		Dir:         tp.Path(),
+		Name:        tp.Name(),
+		TypesPkg:    tp,
+		Arch:        p.Arch,
+		foreign:     true,
+		imports:     p.imports,
+		importer:    p.typesImporter(),
+		BasicTypes:  make(map[string]*Basic),
+		StructTypes: make(map[string]*Struct),
+		ArrayTypes:  make(map[string]*Array),
+		MapTypes:    make(map[string]*Map),
+		NamedTypes:  make(map[string]*Named),
+		Funcs:       make(map[string]*Func),
+		Vars:        make(map[string]*Var),
+		Consts:      make(map[string]*Const),
+	}
+	p.imports[tp.Path()] = ip
+	return ip
+}
+
+// ImportedStruct returns the model of a struct declared in another package.
+func (p *Package) ImportedStruct(named *types.Named) (*Struct, bool) {
+	st, ok := named.Underlying().(*types.Struct)
+	if !ok || named.Obj().Pkg() == nil {
+		return nil, false
+	}
+	ip := p.Imported(named.Obj().Pkg())
+	name := named.Obj().Name()
+	if s, ok := ip.StructTypes[name]; ok {
+		return s, true
+	}
+	s := NewStruct(name, st, ip)
+	if ip.Arch != "" {
+		if sizes := types.SizesFor("gc", ip.Arch); sizes != nil {
+			s.computeLayout(sizes)
+		}
+	}
+	ip.StructTypes[name] = s
+	return s, true
+}
+
+// typesImporter returns the importer of p, the default one is created on
+// first use and shared by the packages reached from p
+func (p *Package) typesImporter() types.Importer {
+	if p.importer == nil {
+		p.importer = importer.Default()
+	}
+	return p.importer
+}
+
+// importedRefStruct loads the package of ref by the importer of p, for
+// models without go/types
+func (p *Package) importedRefStruct(ref *TypeRef) (*Struct, bool) {
+	var tp *types.Package
+	if ip, ok := p.imports[ref.Pkg]; ok {
+		tp = ip.TypesPkg
+	} else {
+		var err error
+		tp, err = p.typesImporter().Import(ref.Pkg)
+		if err != nil {
+			log.WithFields(logrus.Fields{
+				"package": ref.Pkg,
+				"error":   err,
+			}).Errorln("import failed")
+			return nil, false
+		}
+	}
+	obj, ok := tp.Scope().Lookup(ref.Name).(*types.TypeName)
+	if !ok {
+		return nil, false
+	}
+	named, ok := obj.Type().(*types.Named)
+	if !ok {
+		return nil, false
+	}
+	return p.ImportedStruct(named)
+}
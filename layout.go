@@ -0,0 +1,117 @@
+package pkgs
+
+import (
+	"fmt"
+	"go/types"
+	"sort"
+)
+
+// ComputeLayout fills Size, Align and OptimalSize of every Struct, and
+// Offset, Size and Align of their fields, using the gc sizes of goarch.
+// Promoted fields reached through an embedded pointer have Offset -1.
+func (p *Package) ComputeLayout(goarch string) error {
+	sizes := types.SizesFor("gc", goarch)
+	if sizes == nil {
+		return fmt.Errorf("unknown GOARCH: %s", goarch)
+	}
+	p.Arch = goarch
+
+	for _, s := range p.StructTypes {
+		s.computeLayout(sizes)
+	}
+	return nil
+}
+
+func (s *Struct) computeLayout(sizes types.Sizes) {
+	s.sizes = sizes
+	s.Size = sizes.Sizeof(s.Underline)
+	s.Align = sizes.Alignof(s.Underline)
+
+	for _, field := range s.allFields() {
+		field.Size = sizes.Sizeof(field.Type())
+		field.Align = sizes.Alignof(field.Type())
+		field.Offset = s.fieldOffset(sizes, field.Name)
+	}
+
+	order := s.optimalOrder()
+	vars := make([]*types.Var, len(order))
+	for i, idx := range order {
+		vars[i] = s.Underline.Field(idx)
+	}
+	s.OptimalSize = sizes.Sizeof(types.NewStruct(vars, nil))
+}
+
+// fieldOffset follows the embedding path of the field
+func (s *Struct) fieldOffset(sizes types.Sizes, name string) int64 {
+	obj, index, _ := types.LookupFieldOrMethod(s.Underline, false, s.Pkg.TypesPkg, name)
+	if _, ok := obj.(*types.Var); !ok {
+		return -1
+	}
+
+	var (
+		offset int64
+		t      types.Type = s.Underline
+	)
+	for i, idx := range index {
+		st, ok := t.Underlying().(*types.Struct)
+		if !ok {
+			return -1
+		}
+		vars := make([]*types.Var, st.NumFields())
+		for j := range vars {
+			vars[j] = st.Field(j)
+		}
+		offset += sizes.Offsetsof(vars)[idx]
+		t = st.Field(idx).Type()
+		if _, ok := t.Underlying().(*types.Pointer); ok && i < len(index)-1 {
+			return -1
+		}
+	}
+	return offset
+}
+
+// Padding returns the bytes wasted by alignment in the declared field order,
+// blank fields are not padding.
+func (s *Struct) Padding() int64 {
+	size := s.Size
+	for i := 0; i < s.Underline.NumFields(); i++ {
+		size -= s.sizes.Sizeof(s.Underline.Field(i).Type())
+	}
+	return size
+}
+
+// OptimalFields returns the literal fields in an order with the least padding:
+// zero sized fields first, then by decreasing alignment, keeping declaration
+// order otherwise. Blank fields take part in the order but are not returned.
+// Field sizes must be computed by ComputeLayout.
+func (s *Struct) OptimalFields() []*Field {
+	var fields []*Field
+	for _, idx := range s.optimalOrder() {
+		if name := s.Underline.Field(idx).Name(); name != "_" {
+			fields = append(fields, s.FieldMap[name])
+		}
+	}
+	return fields
+}
+
+// optimalOrder returns the indexes of all the fields of Underline,
+// including blank ones, in the order of OptimalFields
+func (s *Struct) optimalOrder() []int {
+	n := s.Underline.NumFields()
+	order := make([]int, n)
+	sizes := make([]int64, n)
+	aligns := make([]int64, n)
+	for i := range order {
+		order[i] = i
+		sizes[i] = s.sizes.Sizeof(s.Underline.Field(i).Type())
+		aligns[i] = s.sizes.Alignof(s.Underline.Field(i).Type())
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := order[i], order[j]
+		if (sizes[a] == 0) != (sizes[b] == 0) {
+			return sizes[a] == 0
+		}
+		return aligns[a] > aligns[b]
+	})
+	return order
+}
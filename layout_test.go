@@ -0,0 +1,43 @@
+package pkgs
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestLayout(t *testing.T) {
+
+	Convey("Compute layout of fixture layout package", t, func() {
+		pkg := NewPackage("fixture/layout")
+		So(pkg.ComputeLayout("amd64"), ShouldBeNil)
+		So(pkg.Arch, ShouldEqual, "amd64")
+
+		hot := pkg.StructTypes["Hot"]
+		So(hot.Size, ShouldEqual, 40)
+		So(hot.Align, ShouldEqual, 8)
+		So(hot.Padding(), ShouldEqual, 14)
+		So(fieldNames(hot.OptimalFields()), ShouldResemble, []string{"B", "Cold", "D", "Inner", "A", "C"})
+		So(hot.OptimalSize, ShouldEqual, 32)
+
+		offsets := make(map[string]int64)
+		for _, field := range hot.allFields() {
+			offsets[field.Name] = field.Offset
+		}
+		So(offsets, ShouldResemble, map[string]int64{
+			"A": 0, "B": 8, "C": 16, "D": 20, "Inner": 24, "Cold": 32,
+			"X": 24, "Y": 26, "Z": -1,
+		})
+
+		blank := pkg.StructTypes["Blank"]
+		So(blank.Size, ShouldEqual, 24)
+		So(blank.Padding(), ShouldEqual, 4)
+		So(fieldNames(blank.OptimalFields()), ShouldResemble, []string{"C", "B", "A"})
+		So(blank.OptimalSize, ShouldEqual, 24)
+
+		So(pkg.ComputeLayout("386"), ShouldBeNil)
+		So(hot.Size, ShouldEqual, 28)
+
+		So(pkg.ComputeLayout("nope"), ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,42 @@
+package pkgs
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestNamed(t *testing.T) {
+
+	Convey("Parse fixture named package", t, func() {
+		pkg := NewPackage("fixture/named")
+		So(pkg.StructTypes["Stamp"], ShouldBeNil)
+		So(pkg.StructTypes["Zone"], ShouldBeNil)
+
+		stamp := pkg.NamedTypes["Stamp"]
+		So(stamp, ShouldNotBeNil)
+		So(stamp.Doc, ShouldEqual, "Stamp is a point in time\n")
+		So(stamp.Base.String(), ShouldEqual, "time.Time")
+		So(stamp.Underlying.Kind, ShouldEqual, "struct")
+		So(pkg.NamedTypes["Zone"].Base.String(), ShouldEqual, "time.Location")
+
+		So(pkg.NamedTypes["Ints"], ShouldBeNil)
+		So(pkg.ArrayTypes["Ints"].String(), ShouldEqual, "[]int")
+		So(pkg.ArrayTypes["Ints"].Base.String(), ShouldEqual, "sort.IntSlice")
+		So(pkg.MapTypes["Counts"].Base, ShouldBeNil)
+		So(pkg.MapTypes["Totals"].Base.String(), ShouldEqual, "Counts")
+		So(pkg.NamedTypes["Stringer"].Base.String(), ShouldEqual, "fmt.Stringer")
+		So(pkg.NamedTypes["Stringer"].Underlying.Kind, ShouldEqual, "interface")
+
+		timeout := pkg.BasicTypes["Timeout"]
+		So(timeout.Type, ShouldEqual, "int64")
+		So(timeout.Base.String(), ShouldEqual, "time.Duration")
+		So(pkg.BasicTypes["Seconds"].Base, ShouldBeNil)
+
+		event := pkg.StructTypes["Event"]
+		So(event.FieldMap["At"].Ref.String(), ShouldEqual, "Stamp")
+		named, err := ParseSelector("named")
+		So(err, ShouldBeNil)
+		So(named.Match(pkg, "Stamp"), ShouldBeTrue)
+	})
+}
@@ -3,23 +3,25 @@
 package pkgs
 
 import (
+	"bytes"
+	"fmt"
 	"go/ast"
 	"go/build"
 	"go/doc"
-	"go/importer"
 	"go/parser"
+	"go/printer"
 	"go/token"
 	"go/types"
 	"io/ioutil"
 	"os"
-	"path"
 	"path/filepath"
 	"strings"
 
+	"github.com/BurntSushi/toml"
 	"github.com/Sirupsen/logrus"
-	"github.com/firewut/go-json-map"
 	"github.com/mitchellh/mapstructure"
 	"github.com/rolldever/go-json5"
+	"gopkg.in/yaml.v2"
 )
 
 var log = logrus.New()
@@ -28,15 +30,34 @@ type Package struct {
 	Dir      string
 	Name     string
 	TypesPkg *types.Package
+	fset     *token.FileSet
+	// bases are the named types on the right of type declarations
+	bases     map[string]*types.Named
+	varValues map[string]string
 
-	Tools map[string]*JsonOptions
-	Dst   string
+	// foreign is set on the models of imported packages, see Imported
+	foreign bool
+	imports map[string]*Package
+	// importer loads the imported packages, shared with imports
+	importer types.Importer
+
+	// Arch is the GOARCH of the memory layout
+	Arch string
+
+	Tools      map[string]*JsonOptions
+	Directives []*Directive
+	Dst        string
 
 	Doc         *doc.Package
 	BasicTypes  map[string]*Basic
 	StructTypes map[string]*Struct
 	ArrayTypes  map[string]*Array
 	MapTypes    map[string]*Map
+	NamedTypes  map[string]*Named
+
+	Funcs  map[string]*Func
+	Vars   map[string]*Var
+	Consts map[string]*Const
 }
 
 func NewPackage(files ...string) *Package {
@@ -53,6 +74,10 @@ func NewPackage(files ...string) *Package {
 		StructTypes: make(map[string]*Struct),
 		ArrayTypes:  make(map[string]*Array),
 		MapTypes:    make(map[string]*Map),
+		NamedTypes:  make(map[string]*Named),
+		Funcs:       make(map[string]*Func),
+		Vars:        make(map[string]*Var),
+		Consts:      make(map[string]*Const),
 	}
 
 	//	for _, t := range typeNames {
@@ -127,6 +152,10 @@ func (p *Package) parsePackage(directory string, names []string, text interface{
 	if err != nil {
 		log.Errorf("new ast package: %s: %s", p.Name, err)
 	}
+	// doc.New may edit the ast, read directives first
+	if err := p.parseDirectives(fs, astFiles); err != nil {
+		log.WithField("error", err).Fatal("Bad directive")
+	}
 	p.Doc = doc.New(astPkg, astFiles[0].Name.String(), doc.AllDecls)
 }
 
@@ -154,12 +183,57 @@ func (p *Package) parseAstFiles(fs *token.FileSet, names []string, text interfac
 
 // check type-checks the package. The package must be OK to proceed.
 func (p *Package) check(fs *token.FileSet, astFiles []*ast.File) {
-	config := types.Config{Importer: importer.Default(), FakeImportC: true}
-	TypesPkg, err := config.Check(p.Dir, fs, astFiles, nil)
+	config := types.Config{Importer: p.typesImporter(), FakeImportC: true}
+	info := &types.Info{Types: make(map[ast.Expr]types.TypeAndValue)}
+	TypesPkg, err := config.Check(p.Dir, fs, astFiles, info)
 	if err != nil {
 		log.Fatalf("checking package: %s", err)
 	}
 	p.TypesPkg = TypesPkg
+	p.fset = fs
+
+	p.scanDecls(fs, astFiles, info)
+}
+
+// scanDecls collects what go/types does not keep: the named types on the
+// right of type declarations and the initializers of package variables.
+func (p *Package) scanDecls(fs *token.FileSet, astFiles []*ast.File, info *types.Info) {
+	p.bases = make(map[string]*types.Named)
+	p.varValues = make(map[string]string)
+	for _, file := range astFiles {
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					if base, ok := unalias(info.Types[spec.Type].Type).(*types.Named); ok && !spec.Assign.IsValid() {
+						p.bases[spec.Name.Name] = base
+					}
+				case *ast.ValueSpec:
+					if gen.Tok != token.VAR || len(spec.Values) != len(spec.Names) {
+						continue
+					}
+					for i, name := range spec.Names {
+						var buf bytes.Buffer
+						if err := printer.Fprint(&buf, fs, spec.Values[i]); err == nil {
+							p.varValues[name.Name] = buf.String()
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// position resolves pos against the type checked files
+func (p *Package) position(pos token.Pos) token.Position {
+	if p.fset == nil {
+		return token.Position{}
+	}
+	return p.fset.Position(pos)
 }
 
 // generateTypes produces the String method for the named type.
@@ -168,9 +242,25 @@ func (p *Package) generateTypes() {
 	scope := p.TypesPkg.Scope()
 	for _, name := range scope.Names() {
 		if obj, ok := scope.Lookup(name).(*types.TypeName); ok {
+			// aliases declare no type
+			if obj.IsAlias() {
+				continue
+			}
+			base, hasBase := p.bases[name]
+			if hasBase && base.Obj().Pkg() != p.TypesPkg {
+				switch base.Underlying().(type) {
+				case *types.Struct, *types.Interface:
+					p.NamedTypes[name] = NewNamed(name, base, p.TypesPkg)
+					continue
+				}
+			}
+
 			switch t := obj.Type().(*types.Named).Underlying().(type) {
 			case *types.Basic:
 				if typ := NewBasic(name, t); typ != nil {
+					if hasBase {
+						typ.Base = NewTypeRef(base, p.TypesPkg)
+					}
 					p.BasicTypes[name] = typ
 				}
 			case *types.Struct:
@@ -179,19 +269,56 @@ func (p *Package) generateTypes() {
 				}
 			case *types.Array:
 				if typ := NewArray(name, t.Elem(), scope); typ != nil {
+					typ.IsArray, typ.Len = true, t.Len()
+					if hasBase {
+						typ.Base = NewTypeRef(base, p.TypesPkg)
+					}
 					p.ArrayTypes[name] = typ
 				}
 			case *types.Slice:
 				if typ := NewArray(name, t.Elem(), scope); typ != nil {
+					if hasBase {
+						typ.Base = NewTypeRef(base, p.TypesPkg)
+					}
 					p.ArrayTypes[name] = typ
 				}
 			case *types.Map:
 				if typ := NewMap(name, t.Key(), t.Elem(), scope); typ != nil {
+					if hasBase {
+						typ.Base = NewTypeRef(base, p.TypesPkg)
+					}
 					p.MapTypes[name] = typ
 				}
 			default:
 				log.WithField(name, t.String()).Infoln("ignore other type")
 			}
+		} else if obj, ok := scope.Lookup(name).(*types.Func); ok {
+			p.Funcs[name] = NewFunc(obj, p)
+		} else if obj, ok := scope.Lookup(name).(*types.Var); ok {
+			p.Vars[name] = NewVar(obj, p)
+		} else if obj, ok := scope.Lookup(name).(*types.Const); ok {
+			p.Consts[name] = NewConst(obj, p)
+		}
+	}
+	p.linkConstructors()
+
+	if err := p.ComputeLayout(build.Default.GOARCH); err != nil {
+		log.Errorln(err)
+	}
+
+	// Load position for all Types
+	for _, name := range scope.Names() {
+		pos := p.position(scope.Lookup(name).Pos())
+		if typ, ok := p.BasicTypes[name]; ok {
+			typ.Pos = pos
+		} else if typ, ok := p.StructTypes[name]; ok {
+			typ.Pos = pos
+		} else if typ, ok := p.ArrayTypes[name]; ok {
+			typ.Pos = pos
+		} else if typ, ok := p.MapTypes[name]; ok {
+			typ.Pos = pos
+		} else if typ, ok := p.NamedTypes[name]; ok {
+			typ.Pos = pos
 		}
 	}
 
@@ -205,42 +332,195 @@ func (p *Package) generateTypes() {
 			typ.Doc = t.Doc
 		} else if typ, ok := p.MapTypes[t.Name]; ok {
 			typ.Doc = t.Doc
+		} else if typ, ok := p.NamedTypes[t.Name]; ok {
+			typ.Doc = t.Doc
+		}
+	}
+
+	// Load doc for all Funcs, Vars and Consts, go/doc groups some of them by type
+	funcs, vars, consts := p.Doc.Funcs, p.Doc.Vars, p.Doc.Consts
+	for _, t := range p.Doc.Types {
+		funcs = append(funcs, t.Funcs...)
+		vars = append(vars, t.Vars...)
+		consts = append(consts, t.Consts...)
+	}
+	for _, f := range funcs {
+		if fn, ok := p.Funcs[f.Name]; ok {
+			fn.Doc = f.Doc
+		}
+	}
+	for _, v := range vars {
+		for _, name := range v.Names {
+			if vr, ok := p.Vars[name]; ok {
+				vr.Doc = v.Doc
+			}
+		}
+	}
+	for _, c := range consts {
+		for _, name := range c.Names {
+			if cn, ok := p.Consts[name]; ok {
+				cn.Doc = c.Doc
+			}
 		}
 	}
 
 	// process json options
+	p.mergeDirectives()
 	for _, opt := range p.Tools {
 		opt.process(p)
 	}
 }
 
+// parseJsonFile loads the optional autogens config of directory and of
+// its parents up to the module root, child configs are deep merged over
+// parent ones. See configFormats for the file names.
 func (p *Package) parseJsonFile(directory string) {
-	js, err := p.readJson(path.Join(directory, "autogens.json5"))
-	if js == nil {
-		js, err = p.readJson(path.Join(directory, "autogens.json"))
+	var merged, own map[string]interface{}
+	for _, dir := range configDirs(directory) {
+		jsraw, err := readConfig(dir)
+		if err != nil {
+			log.WithField("directory", dir).Fatal(err)
+		}
+		if jsraw != nil {
+			merged, _ = mergeOption(merged, jsraw).(map[string]interface{})
+		}
+		own = jsraw
 	}
+	p.Tools = decodeTools(merged)
+
+	// Types keys of parent configs only, the package may not declare them
+	for tool, opt := range p.Tools {
+		ownTypes, _ := own[tool].(map[string]interface{})
+		ownKeys, _ := ownTypes["Types"].(map[string]interface{})
+		for key := range opt.Types {
+			if _, ok := ownKeys[key]; !ok {
+				if opt.inherited == nil {
+					opt.inherited = make(map[string]bool)
+				}
+				opt.inherited[key] = true
+			}
+		}
+	}
+}
+
+// configDirs returns the directories from the module root down to
+// directory. The module root has a go.mod or is the root of a git
+// repository. Without a module root only directory is returned.
+func configDirs(directory string) []string {
+	dir, err := filepath.Abs(directory)
 	if err != nil {
 		log.WithField("directory", directory).Fatal(err)
 	}
-	p.Tools = js
+	var dirs []string
+	for {
+		dirs = append([]string{dir}, dirs...)
+		if isFile(filepath.Join(dir, "go.mod")) || exists(filepath.Join(dir, ".git")) {
+			return dirs
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dirs[len(dirs)-1:]
+		}
+		dir = parent
+	}
 }
 
-func (p *Package) readJson(jsonPath string) (map[string]*JsonOptions, error) {
-	jsraw := make(map[string]interface{})
+// configFormats are the config file names of a directory in order of
+// precedence, only the first one found is read.
+var configFormats = []struct {
+	name      string
+	unmarshal func(data []byte, v *map[string]interface{}) error
+	// json5 sources give the positions of ConfigError
+	json5 bool
+}{
+	{"autogens.json5", func(data []byte, v *map[string]interface{}) error { return json5.Unmarshal(data, v) }, true},
+	{"autogens.json", func(data []byte, v *map[string]interface{}) error { return json5.Unmarshal(data, v) }, true},
+	{"autogens.yaml", func(data []byte, v *map[string]interface{}) error { return yaml.Unmarshal(data, v) }, false},
+	{"autogens.yml", func(data []byte, v *map[string]interface{}) error { return yaml.Unmarshal(data, v) }, false},
+	{"autogens.toml", func(data []byte, v *map[string]interface{}) error { return toml.Unmarshal(data, v) }, false},
+}
 
-	bDoc, err := ioutil.ReadFile(jsonPath)
-	if err != nil {
-		return nil, err
+// readConfig reads the config of dir, nil if dir has none. Values are
+// normalized to what json5 decodes, so all formats behave the same, and
+// validated against ConfigSchema.
+func readConfig(dir string) (map[string]interface{}, error) {
+	for _, format := range configFormats {
+		configPath := filepath.Join(dir, format.name)
+		if !isFile(configPath) {
+			continue
+		}
+		bDoc, err := ioutil.ReadFile(configPath)
+		if err != nil {
+			return nil, err
+		}
+		raw := make(map[string]interface{})
+		if err = format.unmarshal(bDoc, &raw); err != nil {
+			return nil, fmt.Errorf("%s: %s", configPath, err)
+		}
+		raw = normalizeConfig(raw).(map[string]interface{})
+
+		var src []byte
+		if format.json5 {
+			src = bDoc
+		}
+		if err = validateConfig(configPath, src, raw); err != nil {
+			return nil, err
+		}
+		return raw, nil
 	}
-	if err = json5.Unmarshal(bDoc, &jsraw); err != nil {
-		return nil, err
+	return nil, nil
+}
+
+// normalizeConfig turns the yaml and toml maps into map[string]interface{},
+// typed slices into []interface{} and numbers into float64.
+func normalizeConfig(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = normalizeConfig(e)
+		}
+		return v
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[fmt.Sprint(k)] = normalizeConfig(e)
+		}
+		return m
+	case []interface{}:
+		for i, e := range v {
+			v[i] = normalizeConfig(e)
+		}
+		return v
+	case []map[string]interface{}:
+		s := make([]interface{}, len(v))
+		for i, e := range v {
+			s[i] = normalizeConfig(e)
+		}
+		return s
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
 	}
+	return v
+}
 
+func decodeTools(jsraw map[string]interface{}) map[string]*JsonOptions {
 	js := make(map[string]*JsonOptions)
 	for tool, optraw := range jsraw {
 		var opt JsonOptions
 
-		if err := mapstructure.Decode(optraw, &opt); err != nil {
+		// ConfigSchema already rejects unknown keys, ErrorUnused keeps the decoding strict
+		decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+			ErrorUnused: true,
+			Result:      &opt,
+		})
+		if err == nil {
+			err = decoder.Decode(optraw)
+		}
+		if err != nil {
 			log.WithFields(logrus.Fields{
 				"tool":  tool,
 				"error": err,
@@ -249,8 +529,7 @@ func (p *Package) readJson(jsonPath string) (map[string]*JsonOptions, error) {
 
 		js[tool] = &opt
 	}
-
-	return js, nil
+	return js
 }
 
 func (p *Package) supported(name string) bool {
@@ -266,75 +545,18 @@ func (p *Package) supported(name string) bool {
 	if _, ok := p.MapTypes[name]; ok {
 		return true
 	}
+	if _, ok := p.NamedTypes[name]; ok {
+		return true
+	}
 	return false
 }
 
-func (p *Package) shouldIgnore(name string, ignores []string) bool {
-	for _, ign := range ignores {
-		yes := false
-		switch ign {
-		case "basic":
-			_, yes = p.BasicTypes[name]
-		case "struct":
-			_, yes = p.StructTypes[name]
-		case "array":
-			_, yes = p.ArrayTypes[name]
-		case "array*":
-			typ, ok := p.ArrayTypes[name]
-			yes = ok && typ.IsPtr
-		case "array&":
-			typ, ok := p.ArrayTypes[name]
-			yes = ok && !typ.IsPtr
-		case "struct_array":
-			typ, ok := p.ArrayTypes[name]
-			yes = ok && typ.IsStruct
-		case "struct_array*":
-			typ, ok := p.ArrayTypes[name]
-			yes = ok && typ.IsStruct && typ.IsPtr
-		case "struct_array&":
-			typ, ok := p.ArrayTypes[name]
-			yes = ok && typ.IsStruct && !typ.IsPtr
-		case "basic_array":
-			typ, ok := p.ArrayTypes[name]
-			yes = ok && !typ.IsStruct
-		case "basic_array*":
-			typ, ok := p.ArrayTypes[name]
-			yes = ok && !typ.IsStruct && typ.IsPtr
-		case "basic_array&":
-			typ, ok := p.ArrayTypes[name]
-			yes = ok && !typ.IsStruct && !typ.IsPtr
-		case "map":
-			_, yes = p.MapTypes[name]
-		case "map*":
-			typ, ok := p.MapTypes[name]
-			yes = ok && typ.IsPtr
-		case "map&":
-			typ, ok := p.MapTypes[name]
-			yes = ok && !typ.IsPtr
-		case "struct_map":
-			typ, ok := p.MapTypes[name]
-			yes = ok && typ.IsStruct
-		case "struct_map*":
-			typ, ok := p.MapTypes[name]
-			yes = ok && typ.IsStruct && typ.IsPtr
-		case "struct_map&":
-			typ, ok := p.MapTypes[name]
-			yes = ok && typ.IsStruct && !typ.IsPtr
-		case "basic_map":
-			typ, ok := p.MapTypes[name]
-			yes = ok && !typ.IsStruct
-		case "basic_map*":
-			typ, ok := p.MapTypes[name]
-			yes = ok && !typ.IsStruct && typ.IsPtr
-		case "basic_map&":
-			typ, ok := p.MapTypes[name]
-			yes = ok && !typ.IsStruct && !typ.IsPtr
-		}
-		if yes {
+func matchSelectors(p *Package, name string, sels []*Selector) bool {
+	for _, sel := range sels {
+		if sel.Match(p, name) {
 			return true
 		}
 	}
-
 	return false
 }
 
@@ -345,37 +567,44 @@ type JsonOptions struct {
 	Ignored map[string]interface{}
 	Types   map[string]interface{}
 	Data    interface{}
+
+	// inherited are the Types keys from parent configs only
+	inherited map[string]bool
 }
 
 // process check supported type and load preset options
 func (opt *JsonOptions) process(p *Package) {
-	ignores := strings.Split(strings.Join(opt.Ignore, ","), ",")
-	for i := range ignores {
-		ignores[i] = strings.TrimSpace(ignores[i])
+	ignores, err := parseSelectors(opt.Ignore)
+	if err != nil {
+		log.WithField("error", err).Fatal("Bad ignore selector")
 	}
 	opt.Ignored = make(map[string]interface{})
-	for typ, typOpt := range opt.Types {
-		if !p.supported(typ) {
-			log.WithField("type", typ).Fatal("Not a supported type")
-		}
-
-		if quote, ok := typOpt.(string); ok && strings.HasPrefix(quote, "&") {
-			preset, err := gjm.GetProperty(opt.Presets, quote[1:])
-			if err != nil {
-				log.WithFields(logrus.Fields{
-					"type":   typ,
-					"preset": quote,
-					"error":  err,
-				}).Fatal("Preset not found")
-			}
-			opt.Types[typ] = preset
-		}
-
-		if p.shouldIgnore(typ, ignores) {
+	// patterns are expanded to type names, with presets resolved
+	opt.Types = opt.expandTypes(p)
+	if err := opt.validateFields(p); err != nil {
+		log.WithField("error", err).Fatal("Bad fields option")
+	}
+	for typ := range opt.Types {
+		if matchSelectors(p, typ, ignores) {
 			opt.Ignored[typ] = opt.Types[typ]
 			delete(opt.Types, typ)
 		}
 	}
+
+	// "&name" in Data references the preset name
+	data, err := opt.resolveOption(opt.Data)
+	if err != nil {
+		log.WithField("error", err).Fatal("Preset of data not resolved")
+	}
+	// Presets are resolved last, the references above need them raw
+	presets, err := opt.resolvePresets()
+	if err != nil {
+		log.WithField("error", err).Fatal("Preset not resolved")
+	}
+	opt.Presets = presets
+
+	// "$Name" in Data references the const Name
+	opt.Data = p.resolveConsts(data)
 }
 
 // isDirectory reports whether the named file is a directory.
@@ -386,3 +615,13 @@ func isDirectory(name string) bool {
 	}
 	return info.IsDir()
 }
+
+func exists(name string) bool {
+	_, err := os.Stat(name)
+	return err == nil
+}
+
+func isFile(name string) bool {
+	info, err := os.Stat(name)
+	return err == nil && !info.IsDir()
+}
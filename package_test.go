@@ -45,12 +45,14 @@ func TestPackage(t *testing.T) {
 		ps := aliceType.ComputePkgTagPaths("VIEW")
 		psResult := []TagPath{
 			{
-				Path:  []string{"Name"},
-				Value: ";lmin(16)",
+				Path:   []string{"Name"},
+				Value:  ";lmin(16)",
+				Fields: []*Field{aliceType.FieldMap["Name"]},
 			},
 			{
-				Path:  []string{"Foo", "Bar"},
-				Value: ";lmax(16)",
+				Path:   []string{"Foo", "Bar"},
+				Value:  ";lmax(16)",
+				Fields: []*Field{aliceType.FieldMap["Foo"], pkg.StructTypes["Foo"].FieldMap["Bar"]},
 			},
 		}
 		So(ps, ShouldResemble, psResult)
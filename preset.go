@@ -0,0 +1,129 @@
+package pkgs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/firewut/go-json-map"
+)
+
+// presetExtends is the key of an option map extending presets, like
+//
+//	{"$extends": "&strict", "max": 32}
+//
+// The value is a "&name" reference or an array of them, merged in order.
+const presetExtends = "$extends"
+
+// PresetCycleError reports presets extending themselves
+type PresetCycleError struct {
+	Chain []string
+}
+
+func (e *PresetCycleError) Error() string {
+	return "preset cycle: " + strings.Join(e.Chain, " -> ")
+}
+
+// preset returns the preset at path name of Presets, resolved.
+func (opt *JsonOptions) preset(name string) (interface{}, error) {
+	return opt.resolvePreset(name, nil)
+}
+
+// resolveOption resolves the preset references at any depth of v: a
+// "&name" string is replaced by the preset, a map with "$extends" is deep
+// merged over its presets. "&&" escapes a literal "&". v is not modified,
+// the result shares no map or array with v and Presets.
+func (opt *JsonOptions) resolveOption(v interface{}) (interface{}, error) {
+	return opt.resolveExtends(v, nil)
+}
+
+// resolvePresets returns the Presets with all references resolved
+func (opt *JsonOptions) resolvePresets() (map[string]interface{}, error) {
+	if opt.Presets == nil {
+		return nil, nil
+	}
+	presets := make(map[string]interface{}, len(opt.Presets))
+	for name := range opt.Presets {
+		preset, err := opt.resolvePreset(name, nil)
+		if err != nil {
+			return nil, err
+		}
+		presets[name] = preset
+	}
+	return presets, nil
+}
+
+func (opt *JsonOptions) resolvePreset(name string, chain []string) (interface{}, error) {
+	for i, onChain := range chain {
+		if onChain == name {
+			cycle := append(append([]string{}, chain[i:]...), name)
+			return nil, &PresetCycleError{Chain: cycle}
+		}
+	}
+	preset, err := gjm.GetProperty(opt.Presets, name)
+	if err != nil {
+		return nil, fmt.Errorf("preset %s not found: %s", name, err)
+	}
+	return opt.resolveExtends(preset, appendPath(chain, name))
+}
+
+func (opt *JsonOptions) resolveExtends(v interface{}, chain []string) (interface{}, error) {
+	switch v := v.(type) {
+	case string:
+		if strings.HasPrefix(v, "&&") {
+			return v[1:], nil
+		}
+		if strings.HasPrefix(v, "&") {
+			return opt.resolvePreset(v[1:], chain)
+		}
+	case []interface{}:
+		resolved := make([]interface{}, len(v))
+		for i, e := range v {
+			var err error
+			if resolved[i], err = opt.resolveExtends(e, chain); err != nil {
+				return nil, err
+			}
+		}
+		return resolved, nil
+	case map[string]interface{}:
+		own := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			if k == presetExtends {
+				continue
+			}
+			var err error
+			if own[k], err = opt.resolveExtends(e, chain); err != nil {
+				return nil, err
+			}
+		}
+
+		extends, ok := v[presetExtends]
+		if !ok {
+			return own, nil
+		}
+
+		var refs []interface{}
+		switch extends := extends.(type) {
+		case string:
+			refs = []interface{}{extends}
+		case []interface{}:
+			refs = extends
+		default:
+			return nil, fmt.Errorf("%s must be a preset reference or an array of them", presetExtends)
+		}
+
+		var merged interface{}
+		for _, ref := range refs {
+			name, ok := ref.(string)
+			if !ok || !strings.HasPrefix(name, "&") || strings.HasPrefix(name, "&&") {
+				return nil, fmt.Errorf("%s: %v is not a preset reference", presetExtends, ref)
+			}
+			base, err := opt.resolvePreset(name[1:], chain)
+			if err != nil {
+				return nil, err
+			}
+			merged = mergeOption(merged, base)
+		}
+		return mergeOption(merged, own), nil
+	}
+	return v, nil
+}
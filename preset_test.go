@@ -0,0 +1,119 @@
+package pkgs
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPreset(t *testing.T) {
+
+	Convey("Resolve preset inheritance of fixture presets package", t, func() {
+		validator := NewPackage("fixture/presets").Tools["validator"]
+
+		strict := map[string]interface{}{
+			"min": float64(1),
+			"max": float64(8),
+			"msg": map[string]interface{}{"lang": "en", "short": false},
+		}
+		So(validator.Types["Profile"], ShouldResemble, strict)
+
+		So(validator.Types["Account"], ShouldResemble, map[string]interface{}{
+			"min": float64(1),
+			"max": float64(32),
+			"msg": map[string]interface{}{"lang": "en", "short": false},
+		})
+		So(validator.Types["User"], ShouldResemble, map[string]interface{}{
+			"min":   float64(2),
+			"max":   float64(16),
+			"msg":   map[string]interface{}{"lang": "en", "short": true},
+			"label": "name",
+		})
+
+		preset, err := validator.preset("strict")
+		So(err, ShouldBeNil)
+		So(preset, ShouldResemble, strict)
+
+		So(validator.Presets["strict"], ShouldResemble, strict)
+	})
+
+	Convey("Resolve nested preset references of fixture presets package", t, func() {
+		docs := NewPackage("fixture/presets").Tools["docs"]
+
+		rules := []interface{}{"required", map[string]interface{}{"max": float64(64)}}
+		So(docs.Presets["rules"], ShouldResemble, rules)
+		So(docs.Presets["account"], ShouldResemble, map[string]interface{}{
+			"rules": rules,
+			"title": "&literal",
+		})
+		So(docs.Types["Account"], ShouldResemble, map[string]interface{}{
+			"fields": map[string]interface{}{
+				"Name": map[string]interface{}{"rules": rules},
+			},
+		})
+		So(docs.Types["User"], ShouldResemble, []interface{}{rules, "&&twice"})
+		So(docs.Data, ShouldResemble, map[string]interface{}{
+			"shared": []interface{}{rules},
+			"note":   "&data",
+		})
+	})
+
+	Convey("Resolve presets into copies", t, func() {
+		opt := &JsonOptions{Presets: map[string]interface{}{
+			"base": map[string]interface{}{
+				"msg":  map[string]interface{}{"lang": "en"},
+				"tags": []interface{}{"a"},
+			},
+		}}
+		resolved, err := opt.resolveOption(map[string]interface{}{presetExtends: "&base", "max": float64(8)})
+		So(err, ShouldBeNil)
+		m := resolved.(map[string]interface{})
+		m["msg"].(map[string]interface{})["lang"] = "de"
+		m["tags"].([]interface{})[0] = "b"
+
+		So(opt.Presets["base"], ShouldResemble, map[string]interface{}{
+			"msg":  map[string]interface{}{"lang": "en"},
+			"tags": []interface{}{"a"},
+		})
+
+		base := map[string]interface{}{"msg": map[string]interface{}{"lang": "en"}}
+		over := map[string]interface{}{"tags": []interface{}{"a"}}
+		merged := mergeOption(base, over).(map[string]interface{})
+		merged["msg"].(map[string]interface{})["lang"] = "de"
+		merged["tags"].([]interface{})[0] = "b"
+		So(base["msg"], ShouldResemble, map[string]interface{}{"lang": "en"})
+		So(over["tags"], ShouldResemble, []interface{}{"a"})
+	})
+
+	Convey("Detect preset cycles", t, func() {
+		opt := &JsonOptions{Presets: map[string]interface{}{
+			"a": map[string]interface{}{"$extends": "&b"},
+			"b": map[string]interface{}{"$extends": []interface{}{"&c", "&a"}},
+			"c": map[string]interface{}{"max": float64(1)},
+			"d": "&d",
+		}}
+
+		_, err := opt.resolveOption("&a")
+		So(err, ShouldNotBeNil)
+		cycle, ok := err.(*PresetCycleError)
+		So(ok, ShouldBeTrue)
+		So(cycle.Chain, ShouldResemble, []string{"a", "b", "a"})
+		So(err.Error(), ShouldEqual, "preset cycle: a -> b -> a")
+
+		_, err = opt.resolveOption(map[string]interface{}{"$extends": "&d"})
+		So(err, ShouldNotBeNil)
+
+		_, err = opt.resolveOption(map[string]interface{}{"$extends": "c"})
+		So(err, ShouldNotBeNil)
+
+		_, err = opt.resolveOption(map[string]interface{}{"deep": []interface{}{"&a"}})
+		So(err, ShouldNotBeNil)
+
+		_, err = opt.resolveOption("&missing")
+		So(err, ShouldNotBeNil)
+
+		v, err := opt.resolveOption(map[string]interface{}{"$extends": "&c", "min": float64(0)})
+		So(err, ShouldBeNil)
+		So(v, ShouldResemble, map[string]interface{}{"max": float64(1), "min": float64(0)})
+	})
+}
@@ -0,0 +1,208 @@
+package pkgs
+
+import (
+	"reflect"
+	"sync"
+)
+
+type reflectTagKey struct {
+	t   reflect.Type
+	tag string
+}
+
+var (
+	reflectTagPathsMu sync.RWMutex
+	reflectTagPaths   = make(map[reflectTagKey][]TagPath)
+)
+
+// TypeTagPaths is the runtime equivalent of Struct.ComputePkgTagPaths for a
+// struct type, with the same embedding semantics as buildFields. The package
+// of t is the local package, unexported fields of structs from other
+// packages are skipped. Fields of the result are built from reflect and
+// have no go/types. Results are cached, callers must not modify them.
+func TypeTagPaths(t reflect.Type, tag string) []TagPath {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	key := reflectTagKey{t, tag}
+
+	reflectTagPathsMu.RLock()
+	ps, ok := reflectTagPaths[key]
+	reflectTagPathsMu.RUnlock()
+	if ok {
+		return ps
+	}
+
+	r := &reflectTagWalker{pkgPath: t.PkgPath(), tag: tag, onPath: map[reflect.Type]bool{t: true}}
+	ps = r.walk(t, nil, nil)
+
+	reflectTagPathsMu.Lock()
+	reflectTagPaths[key] = ps
+	reflectTagPathsMu.Unlock()
+	return ps
+}
+
+type reflectTagWalker struct {
+	pkgPath string
+	tag     string
+	onPath  map[reflect.Type]bool
+}
+
+func (r *reflectTagWalker) walk(t reflect.Type, parent []string, parentFields []*Field) (ps []TagPath) {
+	foreign := t.PkgPath() != r.pkgPath
+	for _, field := range reflectIntuitiveFields(t) {
+		if foreign && !field.Exported {
+			continue
+		}
+		fields := parentFields
+		for _, hop := range reflectPromotedFields(t, field.Name) {
+			fields = appendField(fields, hop)
+		}
+		if v := field.Tag.Get(r.tag); v != "" {
+			ps = append(ps, TagPath{
+				Path:   appendPath(parent, field.Name),
+				Value:  v,
+				Fields: fields,
+			})
+		} else if suffix, sub, ok := reflectFieldStruct(field); ok && !r.onPath[sub] {
+			r.onPath[sub] = true
+			ps = append(ps, r.walk(sub, appendPath(parent, field.Name+suffix), fields)...)
+			delete(r.onPath, sub)
+		}
+	}
+	return
+}
+
+// reflectPromotedFields is promotedFields for reflect, every field is
+// relative to the package of the struct declaring it
+func reflectPromotedFields(t reflect.Type, name string) (fields []*Field) {
+	sf, _ := t.FieldByName(name)
+	for _, idx := range sf.Index {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		f := t.Field(idx)
+		fields = append(fields, newReflectField(f, t.PkgPath()))
+		t = f.Type
+	}
+	return
+}
+
+// reflectFieldStruct is fieldStruct for reflect, only named structs count
+func reflectFieldStruct(field *Field) (suffix string, t reflect.Type, ok bool) {
+	t = field.reflectType
+	for {
+		switch t.Kind() {
+		case reflect.Ptr:
+			t = t.Elem()
+		case reflect.Slice, reflect.Array:
+			suffix, t = suffix+"[]", t.Elem()
+		case reflect.Map:
+			suffix, t = suffix+"{}", t.Elem()
+		case reflect.Struct:
+			return suffix, t, t.Name() != ""
+		default:
+			return "", nil, false
+		}
+	}
+}
+
+// reflectIntuitiveFields is buildFields for reflect: BFS over embedded structs,
+// names declared twice at the same depth are dropped at every depth.
+// Like the model, field types are relative to the package of t.
+func reflectIntuitiveFields(t reflect.Type) (intuitive []*Field) {
+	pkgPath := t.PkgPath()
+	var (
+		ts       = []reflect.Type{t}
+		fieldMap = make(map[string]bool)
+		conflict = make(map[string]bool)
+	)
+	for len(ts) > 0 {
+		var (
+			nextDeep    []reflect.Type
+			levelFields []*Field
+			levelMap    = make(map[string]bool)
+		)
+		for _, t := range ts {
+			for i := 0; i < t.NumField(); i++ {
+				field := newReflectField(t.Field(i), pkgPath)
+				name := field.Name
+				if conflict[name] || fieldMap[name] {
+					continue
+				}
+				if levelMap[name] {
+					conflict[name] = true
+					continue
+				}
+				levelFields = append(levelFields, field)
+				levelMap[name] = true
+			}
+		}
+
+		for _, field := range levelFields {
+			if conflict[field.Name] {
+				continue
+			}
+			fieldMap[field.Name] = true
+			elem := field.reflectType
+			if elem.Kind() == reflect.Ptr {
+				elem = elem.Elem()
+			}
+			if field.Anonymous && elem.Kind() == reflect.Struct {
+				nextDeep = append(nextDeep, elem)
+				continue
+			}
+			intuitive = append(intuitive, field)
+		}
+		ts = nextDeep
+	}
+	return
+}
+
+func newReflectField(sf reflect.StructField, pkgPath string) *Field {
+	ref := reflectTypeRef(sf.Type, pkgPath)
+	return &Field{
+		Name:        sf.Name,
+		Anonymous:   sf.Anonymous,
+		Exported:    sf.PkgPath == "",
+		TypeString:  ref.String(),
+		Ref:         ref,
+		IsPtr:       sf.Type.Kind() == reflect.Ptr,
+		Tag:         sf.Tag,
+		reflectType: sf.Type,
+	}
+}
+
+// reflectTypeRef is NewTypeRef for reflect, named types of pkgPath are
+// not qualified.
+func reflectTypeRef(t reflect.Type, pkgPath string) *TypeRef {
+	if t.Name() != "" {
+		if t.PkgPath() == "" {
+			return &TypeRef{Kind: "basic", Name: t.Name()}
+		}
+		ref := &TypeRef{Kind: "named", Name: t.Name()}
+		if t.PkgPath() != pkgPath {
+			ref.Pkg = t.PkgPath()
+		}
+		return ref
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return &TypeRef{Kind: "pointer", Elem: reflectTypeRef(t.Elem(), pkgPath)}
+	case reflect.Slice:
+		return &TypeRef{Kind: "slice", Elem: reflectTypeRef(t.Elem(), pkgPath)}
+	case reflect.Array:
+		return &TypeRef{Kind: "array", Len: int64(t.Len()), Elem: reflectTypeRef(t.Elem(), pkgPath)}
+	case reflect.Map:
+		return &TypeRef{Kind: "map", Key: reflectTypeRef(t.Key(), pkgPath), Elem: reflectTypeRef(t.Elem(), pkgPath)}
+	case reflect.Chan:
+		return &TypeRef{Kind: "chan", Elem: reflectTypeRef(t.Elem(), pkgPath)}
+	case reflect.Struct:
+		return &TypeRef{Kind: "struct", Name: t.String()}
+	case reflect.Interface:
+		return &TypeRef{Kind: "interface", Name: t.String()}
+	case reflect.Func:
+		return &TypeRef{Kind: "signature", Name: t.String()}
+	}
+	return &TypeRef{Kind: "unknown", Name: t.String()}
+}
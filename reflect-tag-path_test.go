@@ -0,0 +1,96 @@
+package pkgs
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/empirefox/pkgs/fixture/cross"
+	"github.com/empirefox/pkgs/fixture/tagpath"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// tagPathCases are checked by both ComputePkgTagPaths and TypeTagPaths
+var tagPathCases = []struct {
+	dir  string
+	typ  reflect.Type
+	tags []string
+}{
+	{"fixture/tagpath", reflect.TypeOf(tagpath.Order{}), []string{"VIEW", "MGR", "json"}},
+	{"fixture/tagpath", reflect.TypeOf(&tagpath.Node{}), []string{"VIEW"}},
+	{"fixture/tagpath", reflect.TypeOf(tagpath.Address{}), []string{"VIEW", "MGR"}},
+	{"fixture/tagpath", reflect.TypeOf(tagpath.Event{}), []string{"VIEW", "json"}},
+	{"fixture/tagpath", reflect.TypeOf(tagpath.Both{}), []string{"VIEW"}},
+	{"fixture/cross", reflect.TypeOf(cross.Cert{}), []string{"asn1"}},
+}
+
+func TestTypeTagPaths(t *testing.T) {
+
+	Convey("Runtime tag paths agree with the package model", t, func() {
+		pkgs := make(map[string]*Package)
+		for _, c := range tagPathCases {
+			pkg, ok := pkgs[c.dir]
+			if !ok {
+				pkg = NewPackage(c.dir)
+				pkgs[c.dir] = pkg
+			}
+			name := c.typ.Name()
+			if name == "" {
+				name = c.typ.Elem().Name()
+			}
+			s := pkg.StructTypes[name]
+			So(s, ShouldNotBeNil)
+
+			for _, tag := range c.tags {
+				want := s.ComputePkgTagPaths(tag)
+				got := TypeTagPaths(c.typ, tag)
+				So(stripFields(got), ShouldResemble, stripFields(want))
+				for i := range want {
+					So(fieldChain(got[i]), ShouldResemble, fieldChain(want[i]))
+				}
+			}
+		}
+	})
+
+	Convey("Runtime fields are promoted like the package model", t, func() {
+		pkg := NewPackage("fixture/tagpath")
+		for _, typ := range []reflect.Type{
+			reflect.TypeOf(tagpath.Both{}),
+			reflect.TypeOf(tagpath.Right{}),
+			reflect.TypeOf(tagpath.Event{}),
+			reflect.TypeOf(tagpath.Order{}),
+		} {
+			var want, got []string
+			for _, field := range pkg.StructTypes[typ.Name()].IntuitiveFields {
+				want = append(want, field.Name)
+			}
+			for _, field := range reflectIntuitiveFields(typ) {
+				got = append(got, field.Name)
+			}
+			So(got, ShouldResemble, want)
+		}
+		So(fieldNames(pkg.StructTypes["Both"].IntuitiveFields), ShouldResemble, []string{"Own", "Note", "Code"})
+	})
+
+	Convey("Runtime tag paths are cached", t, func() {
+		typ := reflect.TypeOf(tagpath.Order{})
+		ps := TypeTagPaths(typ, "VIEW")
+		So(len(ps), ShouldEqual, 8)
+		So(&TypeTagPaths(typ, "VIEW")[0], ShouldEqual, &ps[0])
+	})
+}
+
+// fieldChain keeps what both models know of the fields on a path
+func fieldChain(tp TagPath) (chain []Field) {
+	for _, field := range tp.Fields {
+		chain = append(chain, Field{
+			Name:       field.Name,
+			Anonymous:  field.Anonymous,
+			Exported:   field.Exported,
+			TypeString: field.TypeString,
+			Ref:        field.Ref,
+			IsPtr:      field.IsPtr,
+			Tag:        field.Tag,
+		})
+	}
+	return
+}
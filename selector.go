@@ -0,0 +1,396 @@
+package pkgs
+
+import (
+	"fmt"
+	"go/ast"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Selector is a boolean expression on the package types, used by Ignore:
+//
+//	kind == array && elem.struct && !ptr
+//	name("Bob*") || has_tag("json") || doc_contains("deprecated")
+//	struct && !exported
+//
+// Identifiers are the kinds basic, struct, array, map, named and
+//
+//	ptr          the elements of the array or map are pointers
+//	elem.struct  the elements of the array or map are structs
+//	elem.basic   the elements of the array or map are basic
+//	exported     the type name is exported
+//
+// kind can be compared by == and != to a kind. The functions take a
+// quoted string: name matches a path.Match glob, has_tag a tag key of any
+// struct field, doc_contains a substring of the doc. Operators are !, &&
+// and || with the Go precedence, and parentheses.
+//
+// The legacy tokens like "struct_array*" and "basic_map&" are aliases,
+// see legacySelectors.
+type Selector struct {
+	Source string
+	expr   selectorExpr
+}
+
+// legacySelectors are the tokens of Ignore before selectors,
+// "*" meant ptr and "&" !ptr
+var legacySelectors = map[string]string{
+	"array*":        "array && ptr",
+	"array&":        "array && !ptr",
+	"struct_array":  "array && elem.struct",
+	"struct_array*": "array && elem.struct && ptr",
+	"struct_array&": "array && elem.struct && !ptr",
+	"basic_array":   "array && !elem.struct",
+	"basic_array*":  "array && !elem.struct && ptr",
+	"basic_array&":  "array && !elem.struct && !ptr",
+	"map*":          "map && ptr",
+	"map&":          "map && !ptr",
+	"struct_map":    "map && elem.struct",
+	"struct_map*":   "map && elem.struct && ptr",
+	"struct_map&":   "map && elem.struct && !ptr",
+	"basic_map":     "map && !elem.struct",
+	"basic_map*":    "map && !elem.struct && ptr",
+	"basic_map&":    "map && !elem.struct && !ptr",
+}
+
+var selectorKinds = map[string]bool{
+	"basic":  true,
+	"struct": true,
+	"array":  true,
+	"map":    true,
+	"named":  true,
+}
+
+var selectorIdents = map[string]bool{
+	"ptr":         true,
+	"elem.struct": true,
+	"elem.basic":  true,
+	"exported":    true,
+}
+
+var selectorFuncs = map[string]bool{
+	"name":         true,
+	"has_tag":      true,
+	"doc_contains": true,
+}
+
+// SelectorError is a syntax error or an unknown identifier at the byte
+// offset Pos of the selector.
+type SelectorError struct {
+	Selector string
+	Pos      int
+	Msg      string
+}
+
+func (e *SelectorError) Error() string {
+	return fmt.Sprintf("selector %q: %d: %s", e.Selector, e.Pos, e.Msg)
+}
+
+// ParseSelector parses a selector expression or a legacy token.
+func ParseSelector(src string) (*Selector, error) {
+	source := strings.TrimSpace(src)
+	if alias, ok := legacySelectors[source]; ok {
+		source = alias
+	}
+	p := &selectorParser{src: source}
+	p.next()
+	expr := p.parseOr()
+	if p.err == nil && p.tok != "" {
+		p.errorf(p.pos, "unexpected %s", p.tok)
+	}
+	if p.err != nil {
+		p.err.Selector = src
+		return nil, p.err
+	}
+	return &Selector{Source: src, expr: expr}, nil
+}
+
+// Match reports whether the type name of p is selected.
+func (sel *Selector) Match(p *Package, name string) bool {
+	return sel.expr.eval(p.selectorSubject(name))
+}
+
+// parseSelectors parses the comma separated selectors of the Ignore list,
+// commas in strings and parentheses do not separate.
+func parseSelectors(ignore []string) ([]*Selector, error) {
+	var sels []*Selector
+	for _, list := range ignore {
+		for _, src := range splitSelectors(list) {
+			src = strings.TrimSpace(src)
+			if src == "" {
+				continue
+			}
+			sel, err := ParseSelector(src)
+			if err != nil {
+				return nil, err
+			}
+			sels = append(sels, sel)
+		}
+	}
+	return sels, nil
+}
+
+func splitSelectors(list string) (srcs []string) {
+	depth, start, quoted := 0, 0, false
+	for i := 0; i < len(list); i++ {
+		switch c := list[i]; {
+		case quoted:
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				quoted = false
+			}
+		case c == '"':
+			quoted = true
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			srcs = append(srcs, list[start:i])
+			start = i + 1
+		}
+	}
+	return append(srcs, list[start:])
+}
+
+// selectorSubject is what selectors know of a type
+type selectorSubject struct {
+	name       string
+	kind       string
+	ptr        bool
+	elemStruct bool
+	doc        string
+	tags       []reflect.StructTag
+}
+
+func (p *Package) selectorSubject(name string) *selectorSubject {
+	sub := &selectorSubject{name: name}
+	if typ, ok := p.BasicTypes[name]; ok {
+		sub.kind, sub.doc = "basic", typ.Doc
+	} else if typ, ok := p.StructTypes[name]; ok {
+		sub.kind, sub.doc = "struct", typ.Doc
+		for _, field := range typ.allFields() {
+			sub.tags = append(sub.tags, field.Tag)
+		}
+	} else if typ, ok := p.ArrayTypes[name]; ok {
+		sub.kind, sub.doc, sub.ptr, sub.elemStruct = "array", typ.Doc, typ.IsPtr, typ.IsStruct
+	} else if typ, ok := p.MapTypes[name]; ok {
+		sub.kind, sub.doc, sub.ptr, sub.elemStruct = "map", typ.Doc, typ.IsPtr, typ.IsStruct
+	} else if typ, ok := p.NamedTypes[name]; ok {
+		sub.kind, sub.doc = "named", typ.Doc
+	}
+	return sub
+}
+
+type selectorExpr interface {
+	eval(sub *selectorSubject) bool
+}
+
+type selectorAnd struct{ x, y selectorExpr }
+type selectorOr struct{ x, y selectorExpr }
+type selectorNot struct{ x selectorExpr }
+type selectorIdent struct{ name string }
+type selectorKind struct {
+	kind string
+	not  bool
+}
+type selectorCall struct{ fn, arg string }
+
+func (e *selectorAnd) eval(sub *selectorSubject) bool { return e.x.eval(sub) && e.y.eval(sub) }
+func (e *selectorOr) eval(sub *selectorSubject) bool  { return e.x.eval(sub) || e.y.eval(sub) }
+func (e *selectorNot) eval(sub *selectorSubject) bool { return !e.x.eval(sub) }
+func (e *selectorKind) eval(sub *selectorSubject) bool {
+	return (sub.kind == e.kind) != e.not
+}
+
+func (e *selectorIdent) eval(sub *selectorSubject) bool {
+	hasElem := sub.kind == "array" || sub.kind == "map"
+	switch e.name {
+	case "ptr":
+		return hasElem && sub.ptr
+	case "elem.struct":
+		return hasElem && sub.elemStruct
+	case "elem.basic":
+		return hasElem && !sub.elemStruct
+	case "exported":
+		return ast.IsExported(sub.name)
+	}
+	return sub.kind == e.name
+}
+
+func (e *selectorCall) eval(sub *selectorSubject) bool {
+	switch e.fn {
+	case "name":
+		ok, _ := path.Match(e.arg, sub.name)
+		return ok
+	case "has_tag":
+		for _, tag := range sub.tags {
+			if _, ok := tag.Lookup(e.arg); ok {
+				return true
+			}
+		}
+		return false
+	case "doc_contains":
+		return strings.Contains(sub.doc, e.arg)
+	}
+	return false
+}
+
+type selectorParser struct {
+	src string
+	off int
+	// tok is the current token, "" at the end, and pos its offset
+	tok string
+	pos int
+	err *SelectorError
+}
+
+func (p *selectorParser) errorf(pos int, format string, args ...interface{}) {
+	if p.err == nil {
+		p.err = &SelectorError{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+	}
+	// stop scanning
+	p.tok, p.off = "", len(p.src)
+}
+
+func (p *selectorParser) next() {
+	for p.off < len(p.src) && (p.src[p.off] == ' ' || p.src[p.off] == '\t') {
+		p.off++
+	}
+	p.pos = p.off
+	if p.off >= len(p.src) {
+		p.tok = ""
+		return
+	}
+
+	rest := p.src[p.off:]
+	for _, op := range []string{"&&", "||", "==", "!=", "!", "(", ")"} {
+		if strings.HasPrefix(rest, op) {
+			p.tok, p.off = op, p.off+len(op)
+			return
+		}
+	}
+
+	switch c := rest[0]; {
+	case c == '"':
+		end := 1
+		for end < len(rest) && rest[end] != '"' {
+			if rest[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(rest) {
+			p.errorf(p.pos, "unterminated string")
+			return
+		}
+		p.tok, p.off = rest[:end+1], p.off+end+1
+	case c == '_' || c == '.' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z'):
+		end := 1
+		for end < len(rest) && isSelectorIdentByte(rest[end]) {
+			end++
+		}
+		p.tok, p.off = rest[:end], p.off+end
+	default:
+		p.errorf(p.pos, "unexpected %q", c)
+	}
+}
+
+func isSelectorIdentByte(c byte) bool {
+	return c == '_' || c == '.' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+func (p *selectorParser) parseOr() selectorExpr {
+	x := p.parseAnd()
+	for p.tok == "||" {
+		p.next()
+		x = &selectorOr{x, p.parseAnd()}
+	}
+	return x
+}
+
+func (p *selectorParser) parseAnd() selectorExpr {
+	x := p.parseUnary()
+	for p.tok == "&&" {
+		p.next()
+		x = &selectorAnd{x, p.parseUnary()}
+	}
+	return x
+}
+
+func (p *selectorParser) parseUnary() selectorExpr {
+	if p.tok == "!" {
+		p.next()
+		return &selectorNot{p.parseUnary()}
+	}
+	return p.parsePrimary()
+}
+
+func (p *selectorParser) parsePrimary() selectorExpr {
+	tok, pos := p.tok, p.pos
+	switch {
+	case tok == "":
+		p.errorf(pos, "selector expected")
+		return nil
+	case tok == "(":
+		p.next()
+		x := p.parseOr()
+		if p.tok != ")" {
+			p.errorf(p.pos, "missing )")
+		}
+		p.next()
+		return x
+	case !isSelectorIdentByte(tok[0]):
+		p.errorf(pos, "unexpected %s", tok)
+		return nil
+	}
+	p.next()
+
+	switch {
+	case tok == "kind":
+		op, opPos := p.tok, p.pos
+		if op != "==" && op != "!=" {
+			p.errorf(opPos, "kind must be compared by == or !=")
+			return nil
+		}
+		p.next()
+		kind, kindPos := p.tok, p.pos
+		if !selectorKinds[kind] {
+			p.errorf(kindPos, "unknown kind %s", kind)
+			return nil
+		}
+		p.next()
+		return &selectorKind{kind: kind, not: op == "!="}
+	case selectorFuncs[tok]:
+		if p.tok != "(" {
+			p.errorf(p.pos, "%s needs a quoted argument", tok)
+			return nil
+		}
+		p.next()
+		arg, err := strconv.Unquote(p.tok)
+		if err != nil || !strings.HasPrefix(p.tok, `"`) {
+			p.errorf(p.pos, "%s needs a quoted argument", tok)
+			return nil
+		}
+		if tok == "name" {
+			if _, err := path.Match(arg, ""); err != nil {
+				p.errorf(p.pos, "bad glob %s", p.tok)
+				return nil
+			}
+		}
+		p.next()
+		if p.tok != ")" {
+			p.errorf(p.pos, "missing )")
+			return nil
+		}
+		p.next()
+		return &selectorCall{fn: tok, arg: arg}
+	case selectorKinds[tok] || selectorIdents[tok]:
+		return &selectorIdent{name: tok}
+	}
+	p.errorf(pos, "unknown identifier %s", tok)
+	return nil
+}
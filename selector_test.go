@@ -0,0 +1,86 @@
+package pkgs
+
+import (
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// selected returns the sorted type names of p matched by src
+func selected(p *Package, src string) []string {
+	sel, err := ParseSelector(src)
+	So(err, ShouldBeNil)
+	var names []string
+	for _, name := range sortedTypeNames(p) {
+		if sel.Match(p, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func TestSelector(t *testing.T) {
+
+	Convey("Select types of fixture foo package", t, func() {
+		files, err := filepath.Glob("./fixture/foo/*.go")
+		So(err, ShouldBeNil)
+		pkg := NewPackage(files...)
+
+		So(selected(pkg, "kind == array && elem.struct && !ptr"), ShouldResemble, []string{"Boys"})
+		So(selected(pkg, "array && (ptr || !elem.struct)"), ShouldResemble, []string{"Boyss", "Ints"})
+		So(selected(pkg, `name("Bo*") && kind != struct`), ShouldResemble, []string{"BobMap", "BobsMap", "Boys", "Boyss"})
+		// Bob promotes the fields of *Foo
+		So(selected(pkg, `has_tag("VIEW")`), ShouldResemble, []string{"Alice", "Bob", "Foo"})
+		So(selected(pkg, `struct && doc_contains("bob")`), ShouldResemble, []string{"Bob"})
+		So(selected(pkg, `map && elem.struct`), ShouldResemble, []string{"BobMap", "BobsMap"})
+		So(selected(pkg, "basic && exported"), ShouldResemble, selected(pkg, "basic"))
+	})
+
+	Convey("Keep the legacy tokens as aliases", t, func() {
+		files, err := filepath.Glob("./fixture/foo/*.go")
+		So(err, ShouldBeNil)
+		pkg := NewPackage(files...)
+
+		So(selected(pkg, "struct_array*"), ShouldResemble, []string{"Boyss"})
+		So(selected(pkg, "struct_array&"), ShouldResemble, []string{"Boys"})
+		So(selected(pkg, "basic_array"), ShouldResemble, []string{"Ints"})
+		So(selected(pkg, "struct_map&"), ShouldResemble, []string{"BobMap"})
+		So(selected(pkg, "map*"), ShouldResemble, []string{"BobsMap"})
+		So(selected(pkg, "basic_map"), ShouldBeEmpty)
+		So(selected(pkg, "array"), ShouldResemble, []string{"Boys", "Boyss", "Ints"})
+
+		sels, err := parseSelectors([]string{"basic, struct_array&"})
+		So(err, ShouldBeNil)
+		So(matchSelectors(pkg, "Boys", sels), ShouldBeTrue)
+		sels, err = parseSelectors([]string{`map, name("X,Y")`})
+		So(err, ShouldBeNil)
+		So(sels, ShouldHaveLength, 2)
+		So(matchSelectors(pkg, "Boys", sels), ShouldBeFalse)
+	})
+
+	Convey("Report selector errors", t, func() {
+		for src, pos := range map[string]int{
+			"structs":                   0,
+			"array && elem.ptr":         9,
+			"kind == list":              8,
+			"kind array":                5,
+			`name(Bob)`:                 5,
+			`name("[")`:                 5,
+			"(array || map":             13,
+			"array map":                 6,
+			"array && ":                 8,
+			`doc_contains("x`:           13,
+			"struct_array* && exported": 12,
+		} {
+			_, err := ParseSelector(src)
+			So(err, ShouldNotBeNil)
+			serr, ok := err.(*SelectorError)
+			So(ok, ShouldBeTrue)
+			So(serr.Pos, ShouldEqual, pos)
+		}
+
+		_, err := parseSelectors([]string{"basic", "struct, arrays"})
+		So(err.Error(), ShouldEqual, `selector "arrays": 0: unknown identifier arrays`)
+	})
+}
@@ -1,10 +1,66 @@
 package pkgs
 
-import "go/types"
+import (
+	"go/types"
+	"sort"
+	"strings"
+)
 
 type TagPath struct {
 	Path  []string
 	Value string
+	// Fields are the fields of every hop of Path, the last one is the leaf.
+	// A promoted field is preceded by the embedded fields promoting it,
+	// every field is the one of the struct declaring it. Field.IsPtr tells
+	// the nil-able hops.
+	Fields []*Field
+}
+
+// Leaf returns the matched field, nil for an empty path
+func (tp TagPath) Leaf() *Field {
+	if len(tp.Fields) == 0 {
+		return nil
+	}
+	return tp.Fields[len(tp.Fields)-1]
+}
+
+// embeddedHop reports whether field is an embedded field promoting the
+// field of the Path segment i, not the field of the segment itself
+func (tp TagPath) embeddedHop(i int, field *Field) bool {
+	return field.Anonymous && i < len(tp.Path) && strings.TrimRight(tp.Path[i], "[]{}") != field.Name
+}
+
+// WirePath renders Path through the names of an encoding tag like json,
+// keeping the "[]" and "{}" suffixes. Embedded structs named by the tag
+// nest the promoted fields under their name, like encoding/json. It
+// returns false if a hop is omitted by "-".
+func (tp TagPath) WirePath(tag string) ([]string, bool) {
+	path := make([]string, 0, len(tp.Fields))
+	i := 0
+	for _, field := range tp.Fields {
+		name := strings.Split(field.Tag.Get(tag), ",")[0]
+		if name == "-" {
+			return nil, false
+		}
+		if tp.embeddedHop(i, field) {
+			if name != "" {
+				path = append(path, name)
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		path = append(path, name+tp.Path[i][len(field.Name):])
+		i++
+	}
+	return path, true
+}
+
+// WireString is WirePath joined by ".", like "foo.bar"
+func (tp TagPath) WireString(tag string) (string, bool) {
+	path, ok := tp.WirePath(tag)
+	return strings.Join(path, "."), ok
 }
 
 func findStruct(p *Package, st *types.Struct) (*Struct, bool) {
@@ -16,23 +72,223 @@ func findStruct(p *Package, st *types.Struct) (*Struct, bool) {
 	return nil, false
 }
 
+// fieldStruct finds the package struct of the field, through pointers,
+// slices, arrays and maps. suffix has "[]" for every slice or array and
+// "{}" for every map on the way.
+func (p *Package) fieldStruct(field *Field) (suffix string, s *Struct, ok bool) {
+	// the model is imported without go/types
+	if field.Type() == nil {
+		return p.refStruct(field.Ref)
+	}
+	return p.typeStruct(field.Type())
+}
+
+func (p *Package) typeStruct(t types.Type) (suffix string, s *Struct, ok bool) {
+	for {
+		// structs of other packages are modeled on demand
+		if named, isNamed := unalias(t).(*types.Named); isNamed && (p.foreign || named.Obj().Pkg() != p.TypesPkg) {
+			if _, isStruct := named.Underlying().(*types.Struct); isStruct {
+				s, ok = p.ImportedStruct(named)
+				return
+			}
+		}
+		switch u := t.Underlying().(type) {
+		case *types.Pointer:
+			t = u.Elem()
+		case *types.Slice:
+			suffix, t = suffix+"[]", u.Elem()
+		case *types.Array:
+			suffix, t = suffix+"[]", u.Elem()
+		case *types.Map:
+			suffix, t = suffix+"{}", u.Elem()
+		case *types.Struct:
+			s, ok = findStruct(p, u)
+			return
+		default:
+			return "", nil, false
+		}
+	}
+}
+
+func (p *Package) refStruct(ref *TypeRef) (suffix string, s *Struct, ok bool) {
+	for {
+		switch ref.Kind {
+		case "pointer":
+			ref = ref.Elem
+		case "slice", "array":
+			suffix, ref = suffix+"[]", ref.Elem
+		case "map":
+			suffix, ref = suffix+"{}", ref.Elem
+		case "named":
+			if ref.Pkg != "" {
+				s, ok = p.importedRefStruct(ref)
+				return
+			}
+			if s, ok := p.StructTypes[ref.Name]; ok {
+				return suffix, s, true
+			}
+			if arr, ok := p.ArrayTypes[ref.Name]; ok && arr.IsStruct {
+				suffix, ref = suffix+"[]", &TypeRef{Kind: "named", Name: arr.Elem}
+			} else if m, ok := p.MapTypes[ref.Name]; ok && m.IsStruct {
+				suffix, ref = suffix+"{}", &TypeRef{Kind: "named", Name: m.Elem}
+			} else {
+				return "", nil, false
+			}
+		default:
+			return "", nil, false
+		}
+	}
+}
+
+// Decision tells the tag path traversal what to do with a field.
+type Decision int
+
+const (
+	// TagDescend skips the field but descends into its struct, if any
+	TagDescend Decision = iota
+	// TagPrune skips the field and its struct
+	TagPrune
+	// TagMatch records the field as a leaf
+	TagMatch
+	// TagMatchDescend records the field and descends into its struct
+	TagMatchDescend
+)
+
+// TagDecider decides on the field at path, value goes to TagPath.Value
+// on a match. path ends with the field segment.
+type TagDecider func(field *Field, path []string) (d Decision, value string)
+
+// TagDecision matches non empty values of tag and prunes at "-".
+func TagDecision(tag string) TagDecider {
+	return func(field *Field, path []string) (Decision, string) {
+		switch v := field.Tag.Get(tag); v {
+		case "":
+			return TagDescend, ""
+		case "-":
+			return TagPrune, ""
+		default:
+			return TagMatch, v
+		}
+	}
+}
+
+// ComputePkgTagPaths returns the paths of all fields having tag, descending
+// into untagged fields of structs, and into their elements for slices
+// ("Items[]"), arrays and maps ("Labels{}"). Structs of imported packages
+// are loaded on demand, only their exported fields are used. A struct
+// already on the path is not descended again.
 func (s *Struct) ComputePkgTagPaths(tag string) []TagPath {
-	return s.computePkgTagPaths(nil, tag)
+	nonEmpty := func(field *Field, path []string) (Decision, string) {
+		if v := field.Tag.Get(tag); v != "" {
+			return TagMatch, v
+		}
+		return TagDescend, ""
+	}
+	return s.QueryTagPaths(map[string]TagDecider{tag: nonEmpty})[tag]
 }
 
-func (s *Struct) computePkgTagPaths(parent []string, tag string) (ps []TagPath) {
+// QueryTags is QueryTagPaths with a TagDecision for every tag, grouped by tag.
+func (s *Struct) QueryTags(tags ...string) map[string][]TagPath {
+	deciders := make(map[string]TagDecider, len(tags))
+	for _, tag := range tags {
+		deciders[tag] = TagDecision(tag)
+	}
+	return s.QueryTagPaths(deciders)
+}
+
+// QueryTagPaths traverses the fields once like ComputePkgTagPaths, asking
+// every decider independently. Results are grouped by the decider keys.
+func (s *Struct) QueryTagPaths(deciders map[string]TagDecider) map[string][]TagPath {
+	groups := make([]string, 0, len(deciders))
+	for group := range deciders {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+
+	ps := make(map[string][]TagPath, len(groups))
+	s.queryTagPaths(nil, nil, groups, deciders, ps, map[*Struct]bool{s: true})
+	return ps
+}
+
+func (s *Struct) queryTagPaths(parent []string, parentFields []*Field, groups []string,
+	deciders map[string]TagDecider, ps map[string][]TagPath, onPath map[*Struct]bool) {
+
 	for _, field := range s.IntuitiveFields {
-		if v := field.Tag.Get(tag); v != "" {
-			ps = append(ps, TagPath{
-				Path:  append(parent, field.Name),
-				Value: v,
-			})
-		} else if ul, ok := field.UnderlineType().(*types.Struct); ok {
-			if sub, ok := findStruct(s.Pkg, ul); ok {
-				subs := sub.computePkgTagPaths(append(parent, field.Name), tag)
-				ps = append(ps, subs...)
+		if s.Pkg.foreign && !field.Exported {
+			continue
+		}
+		path := appendPath(parent, field.Name)
+		fields := parentFields
+		if promoted, ok := s.promotedFields(field.Name); ok {
+			for _, hop := range promoted {
+				fields = appendField(fields, hop)
+			}
+		} else {
+			fields = appendField(fields, field)
+		}
+
+		var descend []string
+		for _, group := range groups {
+			d, v := deciders[group](field, path)
+			if d == TagMatch || d == TagMatchDescend {
+				ps[group] = append(ps[group], TagPath{Path: path, Value: v, Fields: fields})
+			}
+			if d == TagDescend || d == TagMatchDescend {
+				descend = append(descend, group)
 			}
 		}
+		if len(descend) == 0 {
+			continue
+		}
+
+		if suffix, sub, ok := s.Pkg.fieldStruct(field); ok && !onPath[sub] {
+			onPath[sub] = true
+			sub.queryTagPaths(appendPath(parent, field.Name+suffix), fields, descend, deciders, ps, onPath)
+			delete(onPath, sub)
+		}
 	}
-	return
+}
+
+// promotedFields returns the embedded fields promoting the intuitive field
+// name, the shallowest first, followed by the field of the struct declaring
+// it. The shallowest declaration wins like in buildFields.
+func (s *Struct) promotedFields(name string) ([]*Field, bool) {
+	type node struct {
+		s    *Struct
+		path []*Field
+	}
+	level := []node{{s: s}}
+	for depth := 0; len(level) > 0 && depth < 16; depth++ {
+		var next []node
+		for _, n := range level {
+			if field, ok := n.s.FieldMap[name]; ok {
+				return appendField(n.path, field), true
+			}
+			for _, field := range n.s.Fields {
+				if !field.Anonymous {
+					continue
+				}
+				suffix, sub, ok := n.s.Pkg.fieldStruct(field)
+				if !ok || suffix != "" {
+					continue
+				}
+				next = append(next, node{sub, appendField(n.path, field)})
+			}
+		}
+		level = next
+	}
+	return nil, false
+}
+
+// appendPath never shares the array of parent between siblings
+func appendPath(parent []string, name string) []string {
+	path := make([]string, len(parent), len(parent)+1)
+	copy(path, parent)
+	return append(path, name)
+}
+
+func appendField(parent []*Field, field *Field) []*Field {
+	fields := make([]*Field, len(parent), len(parent)+1)
+	copy(fields, parent)
+	return append(fields, field)
 }
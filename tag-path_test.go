@@ -0,0 +1,176 @@
+package pkgs
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTagPath(t *testing.T) {
+
+	Convey("Compute tag paths of fixture tagpath package", t, func() {
+		pkg := NewPackage("fixture/tagpath")
+		order := pkg.StructTypes["Order"]
+		So(order, ShouldNotBeNil)
+
+		psResult := []TagPath{
+			{Path: []string{"Items[]", "Title"}, Value: ";lmin(1)"},
+			{Path: []string{"Ptrs[]", "Title"}, Value: ";lmin(1)"},
+			{Path: []string{"Labels{}", "Street"}, Value: ";lmax(64)"},
+			{Path: []string{"Labels{}", "City"}, Value: "city"},
+			{Path: []string{"Matrix[][]", "Title"}, Value: ";lmin(1)"},
+			{Path: []string{"Root", "Name"}, Value: "name"},
+			{Path: []string{"Address", "Street"}, Value: ";lmax(64)"},
+			{Path: []string{"Address", "City"}, Value: "city"},
+		}
+		So(stripFields(order.ComputePkgTagPaths("VIEW")), ShouldResemble, psResult)
+
+		data, err := json.Marshal(pkg)
+		So(err, ShouldBeNil)
+		var imported Package
+		So(json.Unmarshal(data, &imported), ShouldBeNil)
+		So(stripFields(imported.StructTypes["Order"].ComputePkgTagPaths("VIEW")), ShouldResemble, psResult)
+	})
+}
+
+func TestImportedTagPath(t *testing.T) {
+
+	Convey("Compute tag paths across packages of fixture cross package", t, func() {
+		pkg := NewPackage("fixture/cross")
+		cert := pkg.StructTypes["Cert"]
+		So(cert, ShouldNotBeNil)
+
+		psResult := []TagPath{
+			{Path: []string{"Name"}, Value: "utf8"},
+			{Path: []string{"Alg", "Parameters"}, Value: "optional"},
+			{Path: []string{"Algs[]", "Parameters"}, Value: "optional"},
+			{Path: []string{"Subject{}", "Value"}, Value: "set"},
+		}
+		So(stripFields(cert.ComputePkgTagPaths("asn1")), ShouldResemble, psResult)
+
+		alg := pkg.imports["crypto/x509/pkix"].StructTypes["AlgorithmIdentifier"]
+		So(alg, ShouldNotBeNil)
+		So(alg.Pkg.Name, ShouldEqual, "pkix")
+		So(alg.Pkg.Imported(alg.Pkg.TypesPkg), ShouldEqual, alg.Pkg)
+		So(alg.Pkg.importer, ShouldEqual, pkg.importer)
+
+		data, err := json.Marshal(pkg)
+		So(err, ShouldBeNil)
+		var imported Package
+		So(json.Unmarshal(data, &imported), ShouldBeNil)
+		So(stripFields(imported.StructTypes["Cert"].ComputePkgTagPaths("asn1")), ShouldResemble, psResult)
+	})
+
+	Convey("Promoted fields of embedded structs from other packages", t, func() {
+		files, err := filepath.Glob("./fixture/foo/*.go")
+		So(err, ShouldBeNil)
+		pkg := NewPackage(files...)
+
+		So(stripFields(pkg.StructTypes["Bob"].ComputePkgTagPaths("sql")), ShouldResemble, []TagPath{
+			{Path: []string{"DeletedAt"}, Value: "index"},
+		})
+	})
+}
+
+func TestQueryTagPaths(t *testing.T) {
+
+	Convey("Query tag paths of fixture tagpath package", t, func() {
+		pkg := NewPackage("fixture/tagpath")
+		order := pkg.StructTypes["Order"]
+
+		groups := order.QueryTags("VIEW", "MGR")
+		So(groups["VIEW"], ShouldResemble, order.ComputePkgTagPaths("VIEW"))
+		So(stripFields(groups["MGR"]), ShouldResemble, []TagPath{
+			{Path: []string{"Items[]", "Price"}, Value: "price"},
+			{Path: []string{"Ptrs[]", "Price"}, Value: "price"},
+			{Path: []string{"Matrix[][]", "Price"}, Value: "price"},
+		})
+		So(stripFields(order.ComputePkgTagPaths("MGR")), ShouldContain, TagPath{Path: []string{"Address", "City"}, Value: "-"})
+
+		ints := func(field *Field, path []string) (Decision, string) {
+			switch {
+			case path[0] == "Root" || path[0] == "Matrix":
+				return TagPrune, ""
+			case field.TypeString == "int" || field.TypeString == "uint":
+				return TagMatch, field.TypeString
+			case field.Name == "Address":
+				return TagMatchDescend, "struct"
+			}
+			return TagDescend, ""
+		}
+		groups = order.QueryTagPaths(map[string]TagDecider{"ints": ints})
+		So(stripFields(groups["ints"]), ShouldResemble, []TagPath{
+			{Path: []string{"ID"}, Value: "uint"},
+			{Path: []string{"Items[]", "Price"}, Value: "int"},
+			{Path: []string{"Ptrs[]", "Price"}, Value: "int"},
+			{Path: []string{"Address"}, Value: "struct"},
+		})
+	})
+}
+
+func TestTagPathFields(t *testing.T) {
+
+	Convey("Tag paths carry fields and wire paths", t, func() {
+		pkg := NewPackage("fixture/tagpath")
+		order := pkg.StructTypes["Order"]
+		address := pkg.StructTypes["Address"]
+
+		ps := order.ComputePkgTagPaths("VIEW")
+		labels := ps[2]
+		So(labels.Path, ShouldResemble, []string{"Labels{}", "Street"})
+		So(labels.Fields, ShouldResemble, []*Field{order.FieldMap["Labels"], address.FieldMap["Street"]})
+		So(labels.Leaf(), ShouldEqual, address.FieldMap["Street"])
+
+		wire, ok := labels.WireString("json")
+		So(ok, ShouldBeTrue)
+		So(wire, ShouldEqual, "labels{}.street")
+
+		root := ps[5]
+		So(root.Fields[0].IsPtr, ShouldBeTrue)
+		wire, ok = root.WireString("json")
+		So(ok, ShouldBeTrue)
+		So(wire, ShouldEqual, "Root.Name")
+
+		_, ok = ps[4].WirePath("json")
+		So(ok, ShouldBeFalse)
+		So(TagPath{}.Leaf(), ShouldBeNil)
+	})
+
+	Convey("Tag paths carry the embedded fields of promoted fields", t, func() {
+		pkg := NewPackage("fixture/tagpath")
+		event := pkg.StructTypes["Event"]
+		audit := pkg.StructTypes["Audit"]
+		stamp := pkg.StructTypes["Stamp"]
+
+		ps := event.ComputePkgTagPaths("VIEW")
+		So(stripFields(ps), ShouldResemble, []TagPath{
+			{Path: []string{"Kind"}, Value: "kind"},
+			{Path: []string{"By"}, Value: "by"},
+			{Path: []string{"At"}, Value: "at"},
+		})
+		So(ps[0].Fields, ShouldResemble, []*Field{event.FieldMap["Kind"]})
+		So(ps[1].Fields, ShouldResemble, []*Field{event.FieldMap["Audit"], audit.FieldMap["By"]})
+		So(ps[1].Fields[0].IsPtr, ShouldBeTrue)
+		So(ps[2].Fields, ShouldResemble, []*Field{event.FieldMap["Stamp"], stamp.FieldMap["At"]})
+		So(ps[2].Leaf(), ShouldEqual, stamp.FieldMap["At"])
+
+		var wires []string
+		for _, tp := range ps {
+			wire, ok := tp.WireString("json")
+			So(ok, ShouldBeTrue)
+			wires = append(wires, wire)
+		}
+		So(wires, ShouldResemble, []string{"kind", "by", "stamp.at"})
+	})
+}
+
+// stripFields keeps Path and Value, for comparing with literals
+func stripFields(ps []TagPath) []TagPath {
+	stripped := make([]TagPath, len(ps))
+	for i, tp := range ps {
+		stripped[i] = TagPath{Path: tp.Path, Value: tp.Value}
+	}
+	return stripped
+}
@@ -0,0 +1,260 @@
+package pkgs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// TagValue is the parsed rule string of a tag value like
+//
+//	label;lmin(1);lmax(16);oneof("a", 'b c');required
+//
+// The first segment is the label, the other segments are rules.
+// Positions are byte offsets in the tag value.
+type TagValue struct {
+	Label    string
+	LabelPos int
+	Rules    []*TagRule
+}
+
+// Rule returns the first rule named name
+func (tv *TagValue) Rule(name string) (*TagRule, bool) {
+	for _, rule := range tv.Rules {
+		if rule.Name == name {
+			return rule, true
+		}
+	}
+	return nil, false
+}
+
+// TagRule is a rule call, Args is nil for a rule without parentheses
+type TagRule struct {
+	Name string
+	Args []*TagArg
+	Pos  int
+	End  int
+}
+
+type TagArgKind int
+
+const (
+	TagArgInt TagArgKind = iota
+	TagArgFloat
+	TagArgString
+	TagArgBool
+	// TagArgIdent is a bare word
+	TagArgIdent
+)
+
+// TagArg is a rule argument. Value is int64, float64, string or bool,
+// a TagArgIdent value is the word.
+type TagArg struct {
+	Kind  TagArgKind
+	Raw   string
+	Value interface{}
+	Pos   int
+}
+
+// TagSyntaxError reports the position of the error in the tag value
+type TagSyntaxError struct {
+	Pos int
+	Msg string
+}
+
+func (e *TagSyntaxError) Error() string {
+	return fmt.Sprintf("tag value: %d: %s", e.Pos, e.Msg)
+}
+
+// ParseTagValue parses the rule string of a tag value.
+func ParseTagValue(s string) (*TagValue, error) {
+	p := &tagValueParser{src: s}
+	return p.parse()
+}
+
+// Rules parses the Value of the tag path
+func (tp TagPath) Rules() (*TagValue, error) {
+	return ParseTagValue(tp.Value)
+}
+
+type tagValueParser struct {
+	src string
+	pos int
+}
+
+func (p *tagValueParser) errorf(pos int, format string, args ...interface{}) error {
+	return &TagSyntaxError{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *tagValueParser) parse() (*TagValue, error) {
+	tv := &TagValue{}
+
+	// the label is everything before the first ';'
+	end := strings.IndexByte(p.src, ';')
+	if end == -1 {
+		end = len(p.src)
+	}
+	label := p.src[:end]
+	tv.Label = strings.TrimSpace(label)
+	tv.LabelPos = strings.Index(label, tv.Label)
+	p.pos = end
+
+	for p.pos < len(p.src) {
+		// skip ';'
+		p.pos++
+		rule, err := p.parseRule()
+		if err != nil {
+			return nil, err
+		}
+		tv.Rules = append(tv.Rules, rule)
+	}
+	return tv, nil
+}
+
+func (p *tagValueParser) skipSpace() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *tagValueParser) parseRule() (*TagRule, error) {
+	p.skipSpace()
+	rule := &TagRule{Pos: p.pos}
+	rule.Name = p.scanWord()
+	if rule.Name == "" || !isIdentStart(rule.Name[0]) {
+		return nil, p.errorf(rule.Pos, "rule name expected")
+	}
+	p.skipSpace()
+
+	if p.pos < len(p.src) && p.src[p.pos] == '(' {
+		p.pos++
+		rule.Args = []*TagArg{}
+		p.skipSpace()
+		if p.pos < len(p.src) && p.src[p.pos] == ')' {
+			p.pos++
+		} else {
+			for {
+				arg, err := p.parseArg()
+				if err != nil {
+					return nil, err
+				}
+				rule.Args = append(rule.Args, arg)
+				p.skipSpace()
+				if p.pos >= len(p.src) {
+					return nil, p.errorf(p.pos, "missing ) of rule %s", rule.Name)
+				}
+				if p.src[p.pos] == ')' {
+					p.pos++
+					break
+				}
+				if p.src[p.pos] != ',' {
+					return nil, p.errorf(p.pos, "unexpected %q in arguments of rule %s", p.src[p.pos], rule.Name)
+				}
+				p.pos++
+			}
+		}
+		p.skipSpace()
+	}
+	rule.End = p.pos
+
+	if p.pos < len(p.src) && p.src[p.pos] != ';' {
+		return nil, p.errorf(p.pos, "unexpected %q after rule %s", p.src[p.pos], rule.Name)
+	}
+	return rule, nil
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c == '.' || c == '-' || c == '+' ||
+		('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+func (p *tagValueParser) scanWord() string {
+	start := p.pos
+	for p.pos < len(p.src) && isWordByte(p.src[p.pos]) {
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+func (p *tagValueParser) parseArg() (*TagArg, error) {
+	p.skipSpace()
+	arg := &TagArg{Pos: p.pos}
+	if p.pos >= len(p.src) {
+		return nil, p.errorf(p.pos, "argument expected")
+	}
+
+	if q := p.src[p.pos]; q == '"' || q == '\'' {
+		s, err := p.scanString(q)
+		if err != nil {
+			return nil, err
+		}
+		arg.Kind, arg.Raw, arg.Value = TagArgString, p.src[arg.Pos:p.pos], s
+		return arg, nil
+	}
+
+	arg.Raw = p.scanWord()
+	if arg.Raw == "" {
+		return nil, p.errorf(p.pos, "unexpected %q, argument expected", p.src[p.pos])
+	}
+	switch {
+	case isNumber(arg.Raw):
+		if i, err := strconv.ParseInt(arg.Raw, 10, 64); err == nil {
+			arg.Kind, arg.Value = TagArgInt, i
+		} else if f, err := strconv.ParseFloat(arg.Raw, 64); err == nil && !strings.ContainsAny(arg.Raw, "xX_") {
+			arg.Kind, arg.Value = TagArgFloat, f
+		} else {
+			return nil, p.errorf(arg.Pos, "bad number %s", arg.Raw)
+		}
+	case arg.Raw == "true" || arg.Raw == "false":
+		arg.Kind, arg.Value = TagArgBool, arg.Raw == "true"
+	default:
+		arg.Kind, arg.Value = TagArgIdent, arg.Raw
+	}
+	return arg, nil
+}
+
+// isNumber reports whether the word starts with a digit, or with a sign
+// followed by a digit. Numbers are decimal, words like inf and nan are
+// identifiers.
+func isNumber(word string) bool {
+	if word != "" && (word[0] == '-' || word[0] == '+') {
+		word = word[1:]
+	}
+	return word != "" && '0' <= word[0] && word[0] <= '9'
+}
+
+// scanString scans a quoted string. Both quotes decode the escapes of Go
+// string literals, \' and \" included.
+func (p *tagValueParser) scanString(quote byte) (string, error) {
+	start := p.pos
+	lit := []byte{'"'}
+	for p.pos++; p.pos < len(p.src); p.pos++ {
+		c := p.src[p.pos]
+		switch {
+		case c == quote:
+			p.pos++
+			s, err := strconv.Unquote(string(append(lit, '"')))
+			if err != nil {
+				return "", p.errorf(start, "bad string: %s", err)
+			}
+			return s, nil
+		case c == '\\' && p.pos+1 < len(p.src):
+			// Unquote of a double quoted literal rejects \'
+			p.pos++
+			if p.src[p.pos] == '\'' {
+				lit = append(lit, '\'')
+			} else {
+				lit = append(lit, c, p.src[p.pos])
+			}
+		case c == '"':
+			lit = append(lit, '\\', '"')
+		default:
+			lit = append(lit, c)
+		}
+	}
+	return "", p.errorf(start, "unterminated string")
+}
@@ -0,0 +1,124 @@
+package pkgs
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParseTagValue(t *testing.T) {
+
+	Convey("Parse rule strings of tag values", t, func() {
+		tv, err := ParseTagValue(";lmax(16)")
+		So(err, ShouldBeNil)
+		So(tv.Label, ShouldEqual, "")
+		So(tv.Rules, ShouldHaveLength, 1)
+		So(tv.Rules[0].Name, ShouldEqual, "lmax")
+		So(tv.Rules[0].Pos, ShouldEqual, 1)
+		So(tv.Rules[0].End, ShouldEqual, 9)
+		So(tv.Rules[0].Args, ShouldResemble, []*TagArg{
+			{Kind: TagArgInt, Raw: "16", Value: int64(16), Pos: 6},
+		})
+
+		tv, err = ParseTagValue(`city; required ;range(-1, 2.5);oneof("a;b", 'c\'d', x, true)`)
+		So(err, ShouldBeNil)
+		So(tv.Label, ShouldEqual, "city")
+		So(tv.Rules, ShouldHaveLength, 3)
+
+		required, ok := tv.Rule("required")
+		So(ok, ShouldBeTrue)
+		So(required.Args, ShouldBeNil)
+		So(required.Pos, ShouldEqual, 6)
+
+		rng, _ := tv.Rule("range")
+		So(rng.Args[0].Value, ShouldEqual, int64(-1))
+		So(rng.Args[1].Kind, ShouldEqual, TagArgFloat)
+		So(rng.Args[1].Value, ShouldEqual, 2.5)
+
+		oneof, _ := tv.Rule("oneof")
+		kinds := []TagArgKind{}
+		values := []interface{}{}
+		for _, arg := range oneof.Args {
+			kinds = append(kinds, arg.Kind)
+			values = append(values, arg.Value)
+		}
+		So(kinds, ShouldResemble, []TagArgKind{TagArgString, TagArgString, TagArgIdent, TagArgBool})
+		So(values, ShouldResemble, []interface{}{"a;b", "c'd", "x", true})
+
+		_, ok = tv.Rule("lmin")
+		So(ok, ShouldBeFalse)
+
+		tv, err = ParseTagValue("name")
+		So(err, ShouldBeNil)
+		So(tv.Label, ShouldEqual, "name")
+		So(tv.Rules, ShouldBeEmpty)
+	})
+
+	Convey("Parse decimal numbers and identifiers", t, func() {
+		tv, err := ParseTagValue(";range(inf, -inf, nan, Infinity, +7, -0.5, 1e3)")
+		So(err, ShouldBeNil)
+		kinds := []TagArgKind{}
+		values := []interface{}{}
+		for _, arg := range tv.Rules[0].Args {
+			kinds = append(kinds, arg.Kind)
+			values = append(values, arg.Value)
+		}
+		So(kinds, ShouldResemble, []TagArgKind{
+			TagArgIdent, TagArgIdent, TagArgIdent, TagArgIdent, TagArgInt, TagArgFloat, TagArgFloat,
+		})
+		So(values, ShouldResemble, []interface{}{"inf", "-inf", "nan", "Infinity", int64(7), -0.5, 1000.0})
+
+		for _, src := range []string{";max(0x1F)", ";max(1_000)", ";max(0x1p4)", ";max(-1x)"} {
+			_, err := ParseTagValue(src)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "bad number")
+		}
+	})
+
+	Convey("Decode escapes of both quotes alike", t, func() {
+		tv, err := ParseTagValue(`;oneof('a\nb', "a\nb", 'x\'y"z', "x\'y\"z", '\u00e9')`)
+		So(err, ShouldBeNil)
+		values := []interface{}{}
+		for _, arg := range tv.Rules[0].Args {
+			values = append(values, arg.Value)
+		}
+		So(values, ShouldResemble, []interface{}{"a\nb", "a\nb", `x'y"z`, `x'y"z`, "\u00e9"})
+
+		_, err = ParseTagValue(`;oneof('\q')`)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Report syntax errors with positions", t, func() {
+		for src, pos := range map[string]int{
+			";1foo":        1,
+			";-x":          1,
+			"a; 2":         3,
+			"a;;b":         2,
+			"a;":           2,
+			";lmax(16":     8,
+			";lmax(16 x)":  9,
+			";lmax(1) x":   9,
+			";lmax(1x)":    6,
+			`;oneof("a)`:   7,
+			";lmax(16,)":   9,
+			";lmax(16)(1)": 9,
+		} {
+			_, err := ParseTagValue(src)
+			So(err, ShouldNotBeNil)
+			serr, ok := err.(*TagSyntaxError)
+			So(ok, ShouldBeTrue)
+			So(serr.Pos, ShouldEqual, pos)
+		}
+	})
+
+	Convey("Parse the values of tag paths", t, func() {
+		order := NewPackage("fixture/tagpath").StructTypes["Order"]
+		for _, tp := range order.ComputePkgTagPaths("VIEW") {
+			tv, err := tp.Rules()
+			So(err, ShouldBeNil)
+			if tv.Label == "" {
+				So(tv.Rules, ShouldHaveLength, 1)
+			}
+		}
+	})
+}
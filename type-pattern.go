@@ -0,0 +1,128 @@
+package pkgs
+
+import (
+	"go/token"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+)
+
+// Ranks of the Types keys, options of higher ranks are merged over lower
+// ones for the types matched by several keys.
+const (
+	// "*" matches all the types
+	typeRankAll = iota
+	// a Selector like "struct && has_tag(\"json\")"
+	typeRankSelector
+	// a regexp between slashes like "/Dto$/"
+	typeRankRegexp
+	// a path.Match glob like "Bob*"
+	typeRankGlob
+	// the exact type name
+	typeRankExact
+)
+
+// typePattern is a key of Types
+type typePattern struct {
+	key   string
+	rank  int
+	match func(name string) bool
+}
+
+// parseTypePattern parses a Types key, an error means the key is neither
+// a type name nor a pattern.
+func (p *Package) parseTypePattern(key string) (*typePattern, error) {
+	tp := &typePattern{key: key}
+	switch {
+	case p.supported(key):
+		tp.rank = typeRankExact
+		tp.match = func(name string) bool { return name == key }
+	case key == "*":
+		tp.rank = typeRankAll
+		tp.match = func(name string) bool { return true }
+	case len(key) > 1 && strings.HasPrefix(key, "/") && strings.HasSuffix(key, "/"):
+		re, err := regexp.Compile(key[1 : len(key)-1])
+		if err != nil {
+			return nil, err
+		}
+		tp.rank = typeRankRegexp
+		tp.match = re.MatchString
+	case strings.ContainsAny(key, "*?[") && isGlob(key):
+		tp.rank = typeRankGlob
+		tp.match = func(name string) bool {
+			ok, _ := path.Match(key, name)
+			return ok
+		}
+	default:
+		sel, err := ParseSelector(key)
+		if err != nil {
+			return nil, err
+		}
+		tp.rank = typeRankSelector
+		tp.match = func(name string) bool { return sel.Match(p, name) }
+	}
+	return tp, nil
+}
+
+func isGlob(key string) bool {
+	_, err := path.Match(key, "")
+	return err == nil
+}
+
+// expandTypes returns the Types keyed by the type names. The options of
+// all keys matching a type are deep merged by rank, then by key, so exact
+// names win over globs, regexps, selectors and "*". Options are resolved
+// before merging, every type has its own copy. Type
+// names inherited from parent configs are skipped if the package does not
+// declare them.
+func (opt *JsonOptions) expandTypes(p *Package) map[string]interface{} {
+	patterns := make([]*typePattern, 0, len(opt.Types))
+	for key := range opt.Types {
+		tp, err := p.parseTypePattern(key)
+		if err != nil && opt.inherited[key] && token.IsIdentifier(key) {
+			// a type of a sibling package
+			continue
+		}
+		if err != nil {
+			log.WithFields(logrus.Fields{
+				"type":  key,
+				"error": err,
+			}).Fatal("Not a supported type")
+		}
+		patterns = append(patterns, tp)
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		if patterns[i].rank != patterns[j].rank {
+			return patterns[i].rank < patterns[j].rank
+		}
+		return patterns[i].key < patterns[j].key
+	})
+
+	names := p.typeNames()
+	sort.Strings(names)
+	types := make(map[string]interface{})
+	for _, tp := range patterns {
+		resolved, err := opt.resolveOption(opt.Types[tp.key])
+		if err != nil {
+			log.WithFields(logrus.Fields{
+				"type":  tp.key,
+				"error": err,
+			}).Fatal("Preset not resolved")
+		}
+		for _, name := range names {
+			if !tp.match(name) {
+				continue
+			}
+			option := resolved
+			if tp.rank != typeRankExact {
+				option = p.pruneFields(name, resolved)
+			}
+			// mergeOption copies, types matched by one key share nothing
+			types[name] = mergeOption(types[name], option)
+		}
+	}
+	return types
+}
@@ -0,0 +1,70 @@
+package pkgs
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTypePattern(t *testing.T) {
+
+	Convey("Expand pattern keys of Types in fixture patterns package", t, func() {
+		pkg := NewPackage("fixture/patterns")
+		mapper := pkg.Tools["mapper"]
+
+		So(mapper.Types, ShouldResemble, map[string]interface{}{
+			"UserDto":  map[string]interface{}{"gen": true, "json": true, "dto": true, "level": float64(2)},
+			"OrderDto": map[string]interface{}{"gen": true, "dto": true, "level": float64(2)},
+			"Bob":      map[string]interface{}{"gen": true, "json": true, "level": float64(4)},
+			"Bobby":    map[string]interface{}{"gen": true, "level": float64(3)},
+			"Levels":   map[string]interface{}{"gen": true, "level": float64(0)},
+		})
+		So(mapper.Ignored, ShouldResemble, map[string]interface{}{
+			"Level": map[string]interface{}{"gen": true, "level": float64(0)},
+		})
+
+		// types matched by one key do not share its option
+		mapper.Types["Levels"].(map[string]interface{})["gen"] = false
+		So(mapper.Ignored["Level"], ShouldResemble, map[string]interface{}{"gen": true, "level": float64(0)})
+	})
+
+	Convey("Copy preset options of pattern keys for every type", t, func() {
+		pkg := NewPackage("fixture/patterns")
+		opt := &JsonOptions{
+			Presets: map[string]interface{}{
+				"base": map[string]interface{}{"tags": map[string]interface{}{"json": true}},
+			},
+			Types: map[string]interface{}{"Bob*": "&base"},
+		}
+		types := opt.expandTypes(pkg)
+		tags := types["Bob"].(map[string]interface{})["tags"].(map[string]interface{})
+		tags["json"] = false
+
+		So(types["Bobby"], ShouldResemble, map[string]interface{}{"tags": map[string]interface{}{"json": true}})
+		So(opt.Presets["base"], ShouldResemble, map[string]interface{}{"tags": map[string]interface{}{"json": true}})
+	})
+
+	Convey("Classify Types keys", t, func() {
+		pkg := NewPackage("fixture/patterns")
+
+		for key, rank := range map[string]int{
+			"Bob":            typeRankExact,
+			"*":              typeRankAll,
+			"/^Bob/":         typeRankRegexp,
+			"Bob?":           typeRankGlob,
+			"[OU]*Dto":       typeRankGlob,
+			"array":          typeRankSelector,
+			`name("Bob")`:    typeRankSelector,
+			"struct && !ptr": typeRankSelector,
+		} {
+			tp, err := pkg.parseTypePattern(key)
+			So(err, ShouldBeNil)
+			So(tp.rank, ShouldEqual, rank)
+		}
+
+		for _, key := range []string{"Missing", "/(/", "Bob&"} {
+			_, err := pkg.parseTypePattern(key)
+			So(err, ShouldNotBeNil)
+		}
+	})
+}
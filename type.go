@@ -3,8 +3,10 @@
 package pkgs
 
 import (
+	"go/token"
 	"go/types"
 	"reflect"
+	"strconv"
 	"strings"
 )
 
@@ -13,9 +15,18 @@ type Field struct {
 	Anonymous     bool
 	Exported      bool
 	TypeString    string
+	Ref           *TypeRef
 	IsPtr         bool
 	Tag           reflect.StructTag
+	Pos           token.Position
+	typ           types.Type
 	underlineType types.Type
+	reflectType   reflect.Type
+
+	// memory layout, see Package.ComputeLayout
+	Offset int64
+	Size   int64
+	Align  int64
 }
 
 func NewField(typesVar *types.Var, tag string, p *Package) *Field {
@@ -23,7 +34,10 @@ func NewField(typesVar *types.Var, tag string, p *Package) *Field {
 		Name:          typesVar.Name(),
 		Anonymous:     typesVar.Anonymous(),
 		Exported:      typesVar.Exported(),
+		Ref:           NewTypeRef(typesVar.Type(), p.TypesPkg),
 		Tag:           reflect.StructTag(tag),
+		Pos:           p.position(typesVar.Pos()),
+		typ:           typesVar.Type(),
 		underlineType: typesVar.Type().Underlying(),
 	}
 	ptr, ok := field.underlineType.Underlying().(*types.Pointer)
@@ -36,6 +50,11 @@ func NewField(typesVar *types.Var, tag string, p *Package) *Field {
 	return field
 }
 
+// Type returns the declared type of the field
+func (field *Field) Type() types.Type {
+	return field.typ
+}
+
 func (field *Field) UnderlineType() types.Type {
 	return field.underlineType
 }
@@ -45,16 +64,41 @@ func (field *Field) UnderlineType() types.Type {
 type Basic struct {
 	Name string
 	Type string
+	// Base is the named type of the declaration, like time.Duration
+	// for "type Timeout time.Duration", nil if declared on a basic type
+	Base *TypeRef
 	Doc  string
+	Pos  token.Position
 }
 
 func NewBasic(name string, t *types.Basic) *Basic {
 	return &Basic{Name: name, Type: t.Name()}
 }
 
+// Named is declared on a struct or interface named type of another
+// package, like "type Stamp time.Time". Types declared on foreign slices
+// and maps are Array and Map types with a Base. The methods of Base are not inherited, so
+// generators usually delegate the marshalling to Base.
+type Named struct {
+	Name       string
+	Base       *TypeRef
+	Underlying *TypeRef
+	Doc        string
+	Pos        token.Position
+}
+
+func NewNamed(name string, base *types.Named, pkg *types.Package) *Named {
+	return &Named{
+		Name:       name,
+		Base:       NewTypeRef(base, pkg),
+		Underlying: NewTypeRef(base.Underlying(), pkg),
+	}
+}
+
 type Struct struct {
 	Name string
 	Doc  string
+	Pos  token.Position
 
 	// all literal fields
 	Fields   []*Field
@@ -67,6 +111,15 @@ type Struct struct {
 
 	Underline *types.Struct
 
+	// Constructors are the NewXxx functions returning this struct
+	Constructors []*Func
+
+	// memory layout, see Package.ComputeLayout
+	Size        int64
+	Align       int64
+	OptimalSize int64
+	sizes       types.Sizes
+
 	Pkg *Package
 }
 
@@ -88,7 +141,14 @@ type Array struct {
 	Elem     string
 	IsStruct bool
 	IsPtr    bool
-	Doc      string
+	// IsArray tells a fixed size array of Len elements from a slice
+	IsArray bool
+	Len     int64
+	// Base is the named type of the declaration, like sort.IntSlice for
+	// "type Ints sort.IntSlice", nil if declared on a slice literal
+	Base *TypeRef
+	Doc  string
+	Pos  token.Position
 }
 
 // NewArray elemTyp is element type
@@ -118,6 +178,17 @@ func NewArray(name string, elemTyp types.Type, scope *types.Scope) *Array {
 	return arr
 }
 
+func (arr *Array) String() string {
+	prefix := "[]"
+	if arr.IsArray {
+		prefix = "[" + strconv.FormatInt(arr.Len, 10) + "]"
+	}
+	if arr.IsPtr {
+		return prefix + "*" + arr.Elem
+	}
+	return prefix + arr.Elem
+}
+
 // Only this package scope element
 type Map struct {
 	Array
@@ -137,10 +208,17 @@ func NewMap(name string, keyTyp, elemTyp types.Type, scope *types.Scope) *Map {
 	return &Map{Array: *arr, Key: kt.Name()}
 }
 
+func (m *Map) String() string {
+	return "map[" + m.Key + "]" + m.Array.String()[2:]
+}
+
 // GetScopeStructType find the struct name from scope
 func GetScopeStructType(et *types.Struct, scope *types.Scope) (string, bool) {
 	for _, n := range scope.Names() {
 		if obj, ok := scope.Lookup(n).(*types.TypeName); ok {
+			if obj.IsAlias() {
+				continue
+			}
 			typ, ok := obj.Type().(*types.Named).Underlying().(*types.Struct)
 			if ok && typ == et {
 				return n, true
@@ -0,0 +1,84 @@
+package pkgs
+
+import (
+	"go/types"
+	"strconv"
+)
+
+// TypeRef is a serializable description of a types.Type. Struct,
+// interface and signature literals are described by their Go syntax in
+// Name only, their fields and methods are not modeled.
+type TypeRef struct {
+	// Kind is one of basic, named, pointer, slice, array, map, struct,
+	// interface, signature, chan
+	Kind string
+	// Name of basic or named type
+	Name string `json:",omitempty"`
+	// Pkg is the import path of a named type from another package
+	Pkg  string   `json:",omitempty"`
+	Len  int64    `json:",omitempty"`
+	Elem *TypeRef `json:",omitempty"`
+	Key  *TypeRef `json:",omitempty"`
+}
+
+// NewTypeRef describes t, named types of pkg are not qualified. Aliases
+// are described by the type they stand for.
+func NewTypeRef(t types.Type, pkg *types.Package) *TypeRef {
+	switch u := unalias(t).(type) {
+	case *types.Basic:
+		return &TypeRef{Kind: "basic", Name: u.Name()}
+	case *types.Named:
+		ref := &TypeRef{Kind: "named", Name: u.Obj().Name()}
+		if p := u.Obj().Pkg(); p != nil && p != pkg {
+			ref.Pkg = p.Path()
+		}
+		return ref
+	case *types.Pointer:
+		return &TypeRef{Kind: "pointer", Elem: NewTypeRef(u.Elem(), pkg)}
+	case *types.Slice:
+		return &TypeRef{Kind: "slice", Elem: NewTypeRef(u.Elem(), pkg)}
+	case *types.Array:
+		return &TypeRef{Kind: "array", Len: u.Len(), Elem: NewTypeRef(u.Elem(), pkg)}
+	case *types.Map:
+		return &TypeRef{Kind: "map", Key: NewTypeRef(u.Key(), pkg), Elem: NewTypeRef(u.Elem(), pkg)}
+	case *types.Chan:
+		return &TypeRef{Kind: "chan", Elem: NewTypeRef(u.Elem(), pkg)}
+	case *types.Struct:
+		return &TypeRef{Kind: "struct", Name: u.String()}
+	case *types.Interface:
+		return &TypeRef{Kind: "interface", Name: u.String()}
+	case *types.Signature:
+		return &TypeRef{Kind: "signature", Name: u.String()}
+	}
+	return &TypeRef{Kind: "unknown", Name: t.String()}
+}
+
+// String returns the Go syntax of the type, qualified by import path
+func (ref *TypeRef) String() string {
+	switch ref.Kind {
+	case "named":
+		if ref.Pkg != "" {
+			return ref.Pkg + "." + ref.Name
+		}
+		return ref.Name
+	case "pointer":
+		return "*" + ref.Elem.String()
+	case "slice":
+		return "[]" + ref.Elem.String()
+	case "array":
+		return "[" + strconv.FormatInt(ref.Len, 10) + "]" + ref.Elem.String()
+	case "map":
+		return "map[" + ref.Key.String() + "]" + ref.Elem.String()
+	case "chan":
+		return "chan " + ref.Elem.String()
+	}
+	return ref.Name
+}
+
+// Deref returns the element of pointer refs
+func (ref *TypeRef) Deref() *TypeRef {
+	for ref.Kind == "pointer" {
+		ref = ref.Elem
+	}
+	return ref
+}
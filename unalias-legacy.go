@@ -0,0 +1,11 @@
+//go:build !go1.22
+// +build !go1.22
+
+package pkgs
+
+import "go/types"
+
+// unalias returns t, go/types has no alias types before go1.22
+func unalias(t types.Type) types.Type {
+	return t
+}
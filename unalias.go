@@ -0,0 +1,11 @@
+//go:build go1.22
+// +build go1.22
+
+package pkgs
+
+import "go/types"
+
+// unalias returns the type an alias like any stands for
+func unalias(t types.Type) types.Type {
+	return types.Unalias(t)
+}
@@ -0,0 +1,204 @@
+package pkgs
+
+import (
+	"go/ast"
+	"go/types"
+)
+
+type Visibility int
+
+const (
+	// VisibilityAll keeps every type and field
+	VisibilityAll Visibility = iota
+	// VisibilityExported keeps exported types and exported fields only
+	VisibilityExported
+	// VisibilityReferenced is VisibilityExported plus the unexported types
+	// reachable from exported ones through exported or embedded fields,
+	// array elements and map elements
+	VisibilityReferenced
+)
+
+// ExportedFields returns the exported literal fields.
+func (s *Struct) ExportedFields() []*Field {
+	return exportedFields(s.Fields)
+}
+
+// ExportedIntuitiveFields returns the exported intuitive fields, including
+// the exported fields promoted from unexported embedded structs.
+func (s *Struct) ExportedIntuitiveFields() []*Field {
+	return exportedFields(s.IntuitiveFields)
+}
+
+func exportedFields(fields []*Field) (exported []*Field) {
+	for _, field := range fields {
+		if field.Exported {
+			exported = append(exported, field)
+		}
+	}
+	return
+}
+
+// Filter returns a copy of the package model restricted to v. Structs are
+// copied with only exported fields; the copies point to the returned Package.
+func (p *Package) Filter(v Visibility) *Package {
+	if v == VisibilityAll {
+		return p
+	}
+
+	keep := make(map[string]bool)
+	for _, name := range p.typeNames() {
+		if ast.IsExported(name) {
+			keep[name] = true
+		}
+	}
+	if v == VisibilityReferenced {
+		p.markReferenced(keep)
+	}
+
+	// the imported models are shared, so is the cache of ImportedStruct
+	if p.imports == nil {
+		p.imports = make(map[string]*Package)
+	}
+	fp := &Package{
+		Dir:         p.Dir,
+		Name:        p.Name,
+		TypesPkg:    p.TypesPkg,
+		fset:        p.fset,
+		bases:       p.bases,
+		varValues:   p.varValues,
+		foreign:     p.foreign,
+		imports:     p.imports,
+		importer:    p.typesImporter(),
+		Arch:        p.Arch,
+		Tools:       p.Tools,
+		Directives:  p.Directives,
+		Dst:         p.Dst,
+		Doc:         p.Doc,
+		BasicTypes:  make(map[string]*Basic),
+		StructTypes: make(map[string]*Struct),
+		ArrayTypes:  make(map[string]*Array),
+		MapTypes:    make(map[string]*Map),
+		NamedTypes:  make(map[string]*Named),
+		Funcs:       make(map[string]*Func),
+		Vars:        make(map[string]*Var),
+		Consts:      make(map[string]*Const),
+	}
+	for name, fn := range p.Funcs {
+		if fn.Exported {
+			fp.Funcs[name] = fn
+		}
+	}
+	for name, vr := range p.Vars {
+		if vr.Exported {
+			fp.Vars[name] = vr
+		}
+	}
+	for name, c := range p.Consts {
+		if c.Exported {
+			fp.Consts[name] = c
+		}
+	}
+	for name, typ := range p.BasicTypes {
+		if keep[name] {
+			fp.BasicTypes[name] = typ
+		}
+	}
+	for name, typ := range p.ArrayTypes {
+		if keep[name] {
+			fp.ArrayTypes[name] = typ
+		}
+	}
+	for name, typ := range p.MapTypes {
+		if keep[name] {
+			fp.MapTypes[name] = typ
+		}
+	}
+	for name, typ := range p.NamedTypes {
+		if keep[name] {
+			fp.NamedTypes[name] = typ
+		}
+	}
+	for name, typ := range p.StructTypes {
+		if keep[name] {
+			fp.StructTypes[name] = typ.exportedCopy(fp)
+		}
+	}
+	return fp
+}
+
+func (s *Struct) exportedCopy(p *Package) *Struct {
+	cp := *s
+	cp.Pkg = p
+	cp.Fields = s.ExportedFields()
+	cp.FieldMap = make(map[string]*Field, len(cp.Fields))
+	for _, field := range cp.Fields {
+		cp.FieldMap[field.Name] = field
+	}
+	cp.Constructors = nil
+	for _, fn := range s.Constructors {
+		if fn.Exported {
+			cp.Constructors = append(cp.Constructors, fn)
+		}
+	}
+	cp.IntuitiveFields = s.ExportedIntuitiveFields()
+	cp.IntuitiveFieldMap = make(map[string]*Field, len(cp.IntuitiveFields))
+	for _, field := range cp.IntuitiveFields {
+		cp.IntuitiveFieldMap[field.Name] = field
+	}
+	return &cp
+}
+
+// markReferenced adds to keep all types reachable from the types already in it
+func (p *Package) markReferenced(keep map[string]bool) {
+	var queue []string
+	for name := range keep {
+		queue = append(queue, name)
+	}
+
+	mark := func(name string) {
+		if !keep[name] && p.supported(name) {
+			keep[name] = true
+			queue = append(queue, name)
+		}
+	}
+
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+
+		if arr, ok := p.ArrayTypes[name]; ok {
+			mark(arr.Elem)
+		}
+		if m, ok := p.MapTypes[name]; ok {
+			mark(m.Elem)
+		}
+		if s, ok := p.StructTypes[name]; ok {
+			for _, field := range s.allFields() {
+				if field.Exported || field.Anonymous {
+					for _, ref := range p.namedRefs(field.Type()) {
+						mark(ref)
+					}
+				}
+			}
+		}
+	}
+}
+
+// namedRefs returns the names of this package types that make up t
+func (p *Package) namedRefs(t types.Type) (names []string) {
+	switch u := t.(type) {
+	case *types.Named:
+		if obj := u.Obj(); obj.Pkg() == p.TypesPkg {
+			names = append(names, obj.Name())
+		}
+	case *types.Pointer:
+		names = p.namedRefs(u.Elem())
+	case *types.Slice:
+		names = p.namedRefs(u.Elem())
+	case *types.Array:
+		names = p.namedRefs(u.Elem())
+	case *types.Map:
+		names = append(p.namedRefs(u.Key()), p.namedRefs(u.Elem())...)
+	}
+	return
+}
@@ -0,0 +1,56 @@
+package pkgs
+
+import (
+	"sort"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFilter(t *testing.T) {
+
+	Convey("Filter fixture visibility package", t, func() {
+		pkg := NewPackage("fixture/visibility")
+		So(pkg.Filter(VisibilityAll), ShouldEqual, pkg)
+
+		exported := pkg.Filter(VisibilityExported)
+		So(sortedTypeNames(exported), ShouldResemble, []string{"Labels", "Public"})
+
+		public := exported.StructTypes["Public"]
+		So(public.Pkg, ShouldEqual, exported)
+		So(fieldNames(public.Fields), ShouldResemble, []string{"ID", "Inner", "Items"})
+		So(fieldNames(public.IntuitiveFields), ShouldResemble, []string{"ID", "Inner", "Items", "Y"})
+		So(public.FieldMap["secret"], ShouldBeNil)
+
+		// the original model is untouched
+		So(len(pkg.StructTypes["Public"].Fields), ShouldEqual, 5)
+
+		referenced := pkg.Filter(VisibilityReferenced)
+		So(sortedTypeNames(referenced), ShouldResemble, []string{"Labels", "Public", "embedded", "inner", "item", "label"})
+	})
+
+	Convey("Keep directives and imported structs in filtered packages", t, func() {
+		pkg := NewPackage("fixture/directive")
+		So(pkg.Filter(VisibilityExported).Directives, ShouldResemble, pkg.Directives)
+
+		pkg = NewPackage("fixture/cross")
+		cert := pkg.StructTypes["Cert"].ComputePkgTagPaths("asn1")
+		So(cert, ShouldNotBeEmpty)
+
+		exported := pkg.Filter(VisibilityExported)
+		So(stripFields(exported.StructTypes["Cert"].ComputePkgTagPaths("asn1")), ShouldResemble, stripFields(cert))
+	})
+}
+
+func sortedTypeNames(p *Package) []string {
+	names := p.typeNames()
+	sort.Strings(names)
+	return names
+}
+
+func fieldNames(fields []*Field) (names []string) {
+	for _, field := range fields {
+		names = append(names, field.Name)
+	}
+	return
+}